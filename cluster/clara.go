@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"github.com/NullHypothesis/mlgo"
+)
+
+// CLARA (Clustering LARge Applications; Kaufman and Rousseeuw, 1990) runs PAM
+// on several samples of the data points, and keeps the medoids of the sample
+// that minimize the total distance of all data points.
+// Distances are precomputed for the samples only; the distances of the other
+// data points to the medoids are computed on demand by Metric.
+type CLARA struct {
+	KMedoids
+	// Number of samples (5 by default, as in R's cluster::clara)
+	Samples int
+	// Number of data points in each sample (40 + 2k by default)
+	SampleSize int
+}
+
+func NewCLARA(X Matrix, metric MetricOp) *CLARA {
+	return &CLARA{ KMedoids: KMedoids{ KMeans: *NewKMeans(X, metric) } }
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+func (c *CLARA) Clone() Cloner {
+	return &CLARA{
+		KMedoids: *c.KMedoids.Clone().(*KMedoids),
+		Samples: c.Samples,
+		SampleSize: c.SampleSize,
+	}
+}
+
+func (c *CLARA) Subset(index []int) Splitter {
+	return &CLARA{
+		KMedoids: *c.KMedoids.Subset(index).(*KMedoids),
+		Samples: c.Samples,
+		SampleSize: c.SampleSize,
+	}
+}
+
+// Cluster runs CLARA.
+// Returns the classification information.
+func (c *CLARA) Cluster(k int) (classes *Classes) {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid.
+func (c *CLARA) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before all samples are processed.
+func (c *CLARA) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	// the data points are sampled by their coordinates
+	if err = c.KMeans.check(k); err != nil {
+		return
+	}
+	m := c.Len()
+	c.K = k
+	c.rng = newRandom(c.Source)
+
+	samples, size := c.Samples, c.SampleSize
+	if samples <= 0 {
+		samples = 5
+	}
+	if size <= 0 {
+		size = 40 + 2 * k
+	}
+	if size >= m {
+		// the sample is the entire data set
+		samples, size = 1, m
+	}
+
+	var best []int
+	bestCost := math.Inf(1)
+	for s := 0; s < samples; s++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// run PAM on the sample, which includes the best medoids so far
+		sample := c.sample(size, best)
+		X := make(Matrix, size)
+		for i, j := range sample {
+			X[i] = c.X[c.Index[j]]
+		}
+		d := &KMedoids{
+			KMeans: KMeans{X: X, Metric: c.Metric, Index: mlgo.Range(0, size),
+				D: NewDistances(X, c.Metric), MaxIter: c.MaxIter, Workers: c.Workers},
+			Algorithm: c.Algorithm,
+		}
+		if d.Algorithm == VoronoiIteration {
+			d.Algorithm = PAM
+		}
+		if _, err = d.ClusterContext(ctx, k); err != nil {
+			return nil, err
+		}
+
+		// assign all data points to the medoids of the sample
+		medoids := make([]int, k)
+		for ii, i := range d.medoids {
+			medoids[ii] = sample[i]
+		}
+		c.setMedoids(medoids)
+		if c.Cost < bestCost {
+			best, bestCost = medoids, c.Cost
+		}
+	}
+	c.setMedoids(best)
+
+	// copy classifcation information
+	classes = c.classes()
+
+	return
+}
+
+// sample draws distinct data points, including the specified data points
+func (c *CLARA) sample(size int, include []int) []int {
+	if size == c.Len() {
+		return mlgo.Range(0, size)
+	}
+	sample := make([]int, 0, size)
+	chosen := make(map[int]bool)
+	for _, i := range include {
+		sample = append(sample, i)
+		chosen[i] = true
+	}
+	for len(sample) < size {
+		if i := c.rng.Intn(c.Len()); !chosen[i] {
+			sample = append(sample, i)
+			chosen[i] = true
+		}
+	}
+	return sample
+}
@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// claraData returns k well separated groups of n data points each, and their partitions
+func claraData(k, n int) (x Matrix, p Partitions) {
+	r := rand.New(rand.NewSource(1))
+	for ii := 0; ii < k; ii++ {
+		for i := 0; i < n; i++ {
+			x = append(x, Vector{float64(ii) * 20 + r.NormFloat64(), r.NormFloat64()})
+			p = append(p, ii)
+		}
+	}
+	return
+}
+
+func TestCLARA(t *testing.T) {
+	x, p := claraData(3, 200)
+	m := len(x)
+	evaluations := 0
+	metric := func(a, b Vector) float64 {
+		evaluations++
+		return Euclidean(a, b)
+	}
+
+	c := NewCLARA(x, metric)
+	c.Source, c.Workers = rand.NewSource(1), 1
+	classes := c.Cluster(3)
+	if !classes.Index.Equal(p) {
+		t.Errorf("CLARA.Cluster(3) got %v, want %v", classes.Index, p)
+	}
+	for ii, i := range c.Medoids {
+		if classes.Index[i] != ii {
+			t.Errorf("CLARA.Cluster(3) got medoid %d in cluster %d, want %d", i, classes.Index[i], ii)
+		}
+	}
+	// distances are only computed for the samples and for the assignment to the medoids
+	if evaluations >= m * (m - 1) / 2 {
+		t.Errorf("CLARA.Cluster(3) got %d distance evaluations, want less than %d", evaluations, m * (m - 1) / 2)
+	}
+}
+
+func TestCLARANS(t *testing.T) {
+	x, p := claraData(3, 50)
+	c := NewCLARANS(x, Euclidean)
+	c.Source = rand.NewSource(1)
+	classes := c.Cluster(3)
+	if !classes.Index.Equal(p) {
+		t.Errorf("CLARANS.Cluster(3) got %v, want %v", classes.Index, p)
+	}
+	if len(c.Medoids) != 3 {
+		t.Fatalf("CLARANS.Cluster(3) got medoids %v", c.Medoids)
+	}
+	for ii, i := range c.Medoids {
+		if classes.Index[i] != ii {
+			t.Errorf("CLARANS.Cluster(3) got medoid %d in cluster %d, want %d", i, classes.Index[i], ii)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"context"
+	"math"
+)
+
+// CLARANS (Clustering Large Applications based on RANdomized Search; Ng and
+// Han, 2002) searches for medoids by exchanging a random medoid with a random
+// non-medoid, as long as an exchange that decreases the total distance is
+// found among MaxNeighbor random exchanges. The search is restarted NumLocal
+// times from random medoids, and the best local minimum is kept.
+// Distances are computed on demand by Metric.
+type CLARANS struct {
+	KMedoids
+	// Number of local minima searched (2 by default)
+	NumLocal int
+	// Maximum number of exchanges examined without improvement
+	// (1.25% of k(m-k), but at least 250 by default)
+	MaxNeighbor int
+}
+
+func NewCLARANS(X Matrix, metric MetricOp) *CLARANS {
+	return &CLARANS{ KMedoids: KMedoids{ KMeans: *NewKMeans(X, metric) } }
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+func (c *CLARANS) Clone() Cloner {
+	return &CLARANS{
+		KMedoids: *c.KMedoids.Clone().(*KMedoids),
+		NumLocal: c.NumLocal,
+		MaxNeighbor: c.MaxNeighbor,
+	}
+}
+
+func (c *CLARANS) Subset(index []int) Splitter {
+	return &CLARANS{
+		KMedoids: *c.KMedoids.Subset(index).(*KMedoids),
+		NumLocal: c.NumLocal,
+		MaxNeighbor: c.MaxNeighbor,
+	}
+}
+
+// Cluster runs CLARANS.
+// Returns the classification information.
+func (c *CLARANS) Cluster(k int) (classes *Classes) {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid.
+func (c *CLARANS) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before the search is completed.
+func (c *CLARANS) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	// the data points are sampled by their coordinates
+	if err = c.KMeans.check(k); err != nil {
+		return
+	}
+	m := c.Len()
+	c.K = k
+	c.rng = newRandom(c.Source)
+
+	local, maxNeighbor := c.NumLocal, c.MaxNeighbor
+	if local <= 0 {
+		local = 2
+	}
+	if maxNeighbor <= 0 {
+		maxNeighbor = int(0.0125 * float64(k * (m - k)))
+		if maxNeighbor < 250 {
+			maxNeighbor = 250
+		}
+	}
+
+	var best []int
+	bestCost := math.Inf(1)
+	for l := 0; l < local; l++ {
+		// start from random medoids
+		c.setMedoids(c.rng.Perm(m)[:k])
+
+		for j := 0; j < maxNeighbor; {
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+			ii, h := c.rng.Intn(k), c.rng.Intn(m)
+			if c.isMedoid(h) {
+				continue
+			}
+			if c.improves(c.swapDelta(ii, h)) {
+				// move to the neighbour and restart the count
+				c.medoids[ii] = h
+				c.assign()
+				j = 0
+			} else {
+				j++
+			}
+		}
+
+		if c.Cost < bestCost {
+			best, bestCost = append([]int(nil), c.medoids...), c.Cost
+		}
+	}
+	c.setMedoids(best)
+
+	// copy classifcation information
+	classes = c.classes()
+
+	return
+}
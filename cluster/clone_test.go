@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	x := Matrix{
+		{-10, -20}, {-10, -18}, { -8, -18}, { -8, -20},
+		{ 10,  20}, { 10,  18}, {  8,  18}, {  8,  20},
+	}
+	clusterers := []Cloner{
+		NewKMeans(x, Euclidean),
+		NewKMedians(x, Manhattan),
+		NewKMedoids(x, Euclidean, nil),
+		NewKMeansElkan(x, Euclidean),
+		NewKMeansHamerly(x, Euclidean),
+		NewMiniBatchKMeans(x, Euclidean, 4),
+		&MixModel{X: x},
+		NewSOM(x, Euclidean, 1, 2),
+	}
+	for i, c := range clusterers {
+		c.Cluster(2)
+		d := c.Clone()
+		if got, want := fmt.Sprintf("%T", d), fmt.Sprintf("%T", c); got != want {
+			t.Errorf("#%d %s.Clone() got %s", i, want, got)
+		}
+		// clustering the clone must not modify the fitted model of the original
+		before := fmt.Sprint(c)
+		d.Cluster(2)
+		if after := fmt.Sprint(c); after != before {
+			t.Errorf("#%d %T.Clone().Cluster(2) modified the original", i, c)
+		}
+	}
+}
+
+func TestCloneSource(t *testing.T) {
+	c := NewKMeans(Matrix{{0}, {1}, {5}, {6}}, Euclidean)
+	if d := c.Clone().(*KMeans); d.Source != nil {
+		t.Errorf("KMeans.Clone() without source got source %v, want nil", d.Source)
+	}
+	// clones draw from their own sources, seeded by the source of the original
+	c.Source = rand.NewSource(1)
+	d, e := c.Clone().(*KMeans), c.Clone().(*KMeans)
+	if d.Source == nil || d.Source == c.Source || d.Source == e.Source {
+		t.Errorf("KMeans.Clone() shares the source of the original")
+	}
+	c.Source = rand.NewSource(1)
+	if f := c.Clone().(*KMeans); f.Source.Int63() != d.Source.Int63() {
+		t.Errorf("KMeans.Clone() with the same source got different sources")
+	}
+}
+
+func TestSubsetSource(t *testing.T) {
+	c := NewKMeans(Matrix{{0}, {1}, {5}, {6}}, Euclidean)
+	c.Source = rand.NewSource(1)
+	d, e := c.Subset([]int{0, 1}).(*KMeans), c.Subset([]int{2, 3}).(*KMeans)
+	if d.Source == nil || d.Source == c.Source || d.Source == e.Source {
+		t.Errorf("KMeans.Subset(...) shares the source of the original")
+	}
+}
+
+func TestFindClusters(t *testing.T) {
+	for i, test := range kmeansTests {
+		c := NewKMeans(test.x, test.metric)
+		classes := FindClusters(c, test.k, 8)
+		if !classes.Index.Equal(test.partitions) {
+			t.Errorf("#%d FindClusters(KMeans, %d, 8) got %v, want %v", i, test.k, classes.Index, test.partitions)
+		}
+		if c.Centers != nil {
+			t.Errorf("#%d FindClusters(KMeans, %d, 8) modified the clusterer", i, test.k)
+		}
+		// the fitted model of the best repeat is returned
+		model, classes := FindModel(c, test.k, 8, nil)
+		d := model.(*KMeans)
+		if p := d.Predict(test.x); !p.Equal(classes.Index) || d.Cost != classes.Cost {
+			t.Errorf("#%d FindModel(KMeans, %d, 8, nil) got model with %v, cost %v, want %v, cost %v", i, test.k, p, d.Cost, classes.Index, classes.Cost)
+		}
+	}
+}
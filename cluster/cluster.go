@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"math/rand"
 	"github.com/NullHypothesis/mlgo"
 )
 
@@ -14,6 +15,9 @@ type Classes struct {
 	Index Partitions
 	K int
 	Cost float64
+	// Total distance of the members of each cluster to its center,
+	// if the clusterer has centers (nil otherwise)
+	Errors Vector
 }
 
 func (c *Classes) Sizes() []int {
@@ -57,30 +61,105 @@ type Subclusterer interface {
 	Subcluster(k int, idx []int) *Classes
 }
 
+// Predictor is implemented by fitted clusterers with cluster centers
+// (KMeans, KMedians, KMedoids), which can assign unseen data points.
+type Predictor interface {
+	// Predict returns the index of the nearest center for each data point of X.
+	Predict(X Matrix) Partitions
+	// Transform returns the distances from each data point of X to each center.
+	Transform(X Matrix) Matrix
+}
+
+// Fitter is implemented by clusterers that validate their input.
+type Fitter interface {
+	// Fit clusters data points into k clusters, or returns an error
+	// (e.g. ErrNoData, KError, NaNError) if the input is invalid.
+	Fit(k int) (*Classes, error)
+}
+
 type Hierarchizer interface {
 	// Hierarchize organizes data clusters in a dendrogram
 	Hierarchize() Linkages
 }
 
-// FIXME There should be multiple instances of Clusterer
+// Cloner is implemented by clusterers whose Cluster method modifies their
+// state (e.g. KMeans, MixModel, SOM), s.t. independent copies can be run
+// concurrently.
+type Cloner interface {
+	Clusterer
+	// Clone returns a copy of the clusterer that shares no mutable state with the original.
+	Clone() Cloner
+}
+
 // FindClusters runs the clustering algorithm for the specified number of repeats.
+// Repeats of a Cloner run concurrently on clones and c is not modified (use
+// FindModel to obtain the fitted model); other clusterers are run sequentially.
 func FindClusters(c Clusterer, k int, repeats int) (classes *Classes) {
-	// repeat clustering concurrently
-	ch := make(chan *Classes)
-	for i := 0; i < repeats; i++ {
-		go func() {
-			ch <- c.Cluster(k)
-		}()
+	if cl, ok := c.(Cloner); ok {
+		_, classes = FindModel(cl, k, repeats, nil)
+		return
+	}
+	results := make([]*Classes, repeats)
+	for i := range results {
+		results[i] = c.Cluster(k)
+	}
+	return bestClasses(results)
+}
+
+// FindClustersSeed runs the clustering algorithm for the specified number of repeats,
+// where repeat i draws random numbers from the i-th source derived from seed by
+// NewSources, s.t. the same seed always yields the same classes.
+// Clusterers that are not Randomized are run by FindClusters.
+func FindClustersSeed(c Clusterer, k int, repeats int, seed int64) (classes *Classes) {
+	cl, ok := c.(Cloner)
+	if _, randomized := c.(Randomized); !ok || !randomized {
+		return FindClusters(c, k, repeats)
+	}
+	_, classes = FindModel(cl, k, repeats, NewSources(seed, repeats))
+	return
+}
+
+// FindModel runs the clustering algorithm concurrently on a clone of c for each repeat
+// and returns the fitted model and the classes of the repeat with the minimum cost.
+// If sources are specified, repeat i of a Randomized clusterer draws from sources[i],
+// otherwise from the source of its clone, which is seeded by the source of c.
+// c itself is not modified.
+func FindModel(c Cloner, k int, repeats int, sources []rand.Source) (model Cloner, classes *Classes) {
+	models := make([]Cloner, repeats)
+	results := make([]*Classes, repeats)
+	for i := range models {
+		models[i] = c.Clone()
+		if r, ok := models[i].(Randomized); ok && sources != nil {
+			r.SetSource(sources[i])
+		}
+	}
+
+	// repeat clustering concurrently, on at most GOMAXPROCS goroutines
+	parallel(repeats, 0, func(start, end int) {
+		for i := start; i < end; i++ {
+			results[i] = models[i].Cluster(k)
+		}
+	})
+
+	classes = bestClasses(results)
+	for i := range results {
+		if results[i] == classes && classes != nil {
+			model = models[i]
+			break
+		}
 	}
+	return
+}
 
-	// determine best clustering
+// bestClasses returns the classes with the minimum cost;
+// in case of ties, the classes of the earliest repeat are chosen
+func bestClasses(results []*Classes) (classes *Classes) {
 	minCost := maxValue
-	for i := 0; i < repeats; i++ {
-		if cl := <-ch; cl.Cost < minCost {
+	for _, cl := range results {
+		if cl != nil && cl.Cost < minCost {
 			classes = cl
 			minCost = cl.Cost
 		}
 	}
 	return
 }
-
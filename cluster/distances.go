@@ -1,38 +1,142 @@
 package cluster
 
 import (
+	"sync"
 	"github.com/NullHypothesis/mlgo"
 )
 
-//TODO make Distances memory efficient
+// DistanceProvider provides the distances between pairs of data points,
+// e.g. from memory (Distances) or from a memory-mapped file (MappedDistances).
+type DistanceProvider interface {
+	// Get returns the distance between data points i and j.
+	Get(i, j int) float64
+	// Len returns the number of data points.
+	Len() int
+	// Subset returns the distances between the subset of data points specified by index,
+	// which indexes the data points of the provider.
+	Subset(index []int) DistanceProvider
+}
 
+// Distances holds the distances between all pairs of data points in condensed
+// form, i.e. the upper triangle of the distance matrix without the diagonal,
+// row by row as returned by SciPy's pdist:
+//   d(0,1), d(0,2), ..., d(0,m-1), d(1,2), ..., d(m-2,m-1)
+// which requires m(m-1)/2 values instead of m^2.
 type Distances struct {
-	// distances between pairs of data points
-	rep Matrix
+	// condensed distances between pairs of data points
+	rep Vector
+	// condensed distances in single precision, used instead of rep if not nil
+	rep32 []float32
+	// number of data points of rep
+	m int
 	metric MetricOp
 	index []int
 }
 
+// NewDistances calculates the distances between all pairs of data points of X
+// (see NewDistancesWith).
 func NewDistances(X Matrix, metric MetricOp) (d *Distances)  {
+	return NewDistancesWith(X, metric, DistancesOptions{})
+}
+
+// NewDistances32 is NewDistances, but stores the distances in single precision,
+// which halves the memory requirement.
+func NewDistances32(X Matrix, metric MetricOp) (d *Distances)  {
+	return NewDistancesWith(X, metric, DistancesOptions{ Float32: true })
+}
+
+// DistancesOptions specifies how the distances are calculated and stored.
+type DistancesOptions struct {
+	// Store the distances in single precision
+	Float32 bool
+	// Maximum number of goroutines; GOMAXPROCS is used if 0.
+	// N.B. unless Workers is 1, the metric is called concurrently and must be
+	// safe for concurrent use, i.e. it must not modify shared state
+	Workers int
+	// Called after each block of distances with the number of distances
+	// calculated so far and the total number, if not nil; calls are serialized
+	Progress func(done, total int)
+}
+
+// distanceBlock is the number of rows and columns of the tiles of the distance
+// matrix that are calculated by one goroutine, s.t. the data points of a tile
+// stay in the cache
+const distanceBlock = 64
+
+// NewDistancesWith calculates the distances between all pairs of data points of X
+// as specified by opts. The distances are calculated concurrently in tiles, s.t.
+// metric is called from multiple goroutines unless opts.Workers is 1.
+// The data points are not checked: distances between points of different
+// dimensions are 0 (see MetricOp).
+func NewDistancesWith(X Matrix, metric MetricOp, opts DistancesOptions) (d *Distances)  {
 	// each row of X is considered one data point
 	m := len(X)
+	total := condensedLen(m)
 
 	// allocate space
-	D := make(Matrix, m)
-	for i := 0; i < m; i++ {
-		D[i] = make(Vector, m)
+	d = &Distances{ m: m, metric: metric, index: mlgo.Range(0, m) }
+	if opts.Float32 {
+		d.rep32 = make([]float32, total)
+	} else {
+		d.rep = make(Vector, total)
 	}
 
-	// calculate distances for lower and upper triangles together
-	for i := 0; i < m; i++ {
-		for j := i + 1; j < m; j++ {
-			d := metric(X[i], X[j])
-			D[i][j], D[j][i] = d, d
+	var mutex sync.Mutex
+	done := 0
+	// calculate the distances of each tile; tiles are taken from a queue,
+	// since tiles on the diagonal hold half as many distances
+	tiles := distanceTiles(m)
+	parallelQueue(len(tiles), opts.Workers, func(t int) {
+		n := d.calculateTile(X, tiles[t][0], tiles[t][1])
+		if opts.Progress != nil {
+			mutex.Lock()
+			done += n
+			opts.Progress(done, total)
+			mutex.Unlock()
 		}
-	}
+	})
 
-	d = &Distances{ rep: D, metric: metric, index: mlgo.Range(0, m) }
+	return
+}
 
+// distanceTiles returns the tiles of the upper triangle of the distance matrix
+// of m data points, as the first rows of their row and column blocks
+func distanceTiles(m int) (tiles [][2]int) {
+	blocks := (m + distanceBlock - 1) / distanceBlock
+	tiles = make([][2]int, 0, blocks * (blocks + 1) / 2)
+	for a := 0; a < blocks; a++ {
+		for b := a; b < blocks; b++ {
+			tiles = append(tiles, [2]int{a * distanceBlock, b * distanceBlock})
+		}
+	}
+	return
+}
+
+// calculateTile calculates the distances between the data points of the row block
+// starting at i0 and the column block starting at j0, i0 <= j0, in the upper triangle
+// Returns the number of distances calculated
+func (d *Distances) calculateTile(X Matrix, i0, j0 int) (n int) {
+	iEnd, jEnd := i0 + distanceBlock, j0 + distanceBlock
+	if iEnd > d.m { iEnd = d.m }
+	if jEnd > d.m { jEnd = d.m }
+	for i := i0; i < iEnd; i++ {
+		j := j0
+		if j <= i {
+			j = i + 1
+		}
+		// distances of row i are contiguous in the condensed form
+		k := condensedIndex(d.m, i, j)
+		for ; j < jEnd; j++ {
+			x := d.metric(X[i], X[j])
+			if d.rep32 != nil {
+				d.rep32[k] = float32(x)
+			} else {
+				d.rep[k] = x
+			}
+			k++
+			n++
+		}
+	}
 	return
 }
 
@@ -40,11 +144,48 @@ func (d *Distances) Len() int {
 	return len(d.index)
 }
 
-func (d *Distances) Subset(index []int) *Distances {
-	return &Distances{ rep: d.rep, metric: d.metric, index: index }
+// Subset returns the distances between the subset of data points specified by index,
+// which indexes the data points of d.
+func (d *Distances) Subset(index []int) DistanceProvider {
+	return &Distances{ rep: d.rep, rep32: d.rep32, m: d.m, metric: d.metric, index: Permute(d.index, index) }
 }
 
 func (d *Distances) Get(i, j int) float64 {
-	return d.rep[d.index[i]][d.index[j]]
+	a, b := d.index[i], d.index[j]
+	if a == b {
+		return 0
+	}
+	k := condensedIndex(d.m, a, b)
+	if d.rep32 != nil {
+		return float64(d.rep32[k])
+	}
+	return d.rep[k]
 }
 
+// Condensed returns the distances between the data points of d in condensed form.
+func (d *Distances) Condensed() (v Vector) {
+	m := d.Len()
+	v = make(Vector, condensedLen(m))
+	k := 0
+	for i := 0; i < m; i++ {
+		for j := i + 1; j < m; j++ {
+			v[k] = d.Get(i, j)
+			k++
+		}
+	}
+	return
+}
+
+// condensedLen returns the number of pairs of m data points
+func condensedLen(m int) int {
+	return m * (m - 1) / 2
+}
+
+// condensedIndex returns the position of the distance between data points a and b,
+// a != b, in the condensed distances of m data points
+func condensedIndex(m, a, b int) int {
+	if a > b {
+		a, b = b, a
+	}
+	return m * a - a * (a + 1) / 2 + b - a - 1
+}
@@ -0,0 +1,245 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"github.com/NullHypothesis/mlgo"
+)
+
+// NewDistancesFromMatrix returns the distances given by the full symmetric matrix D,
+// e.g. dissimilarities calculated by external tools. Returns an error if D is not
+// square or symmetric, has a non-zero diagonal or contains NaN.
+func NewDistancesFromMatrix(D Matrix) (*Distances, error) {
+	m := len(D)
+	v := make(Vector, 0, condensedLen(m))
+	for i := 0; i < m; i++ {
+		if len(D[i]) != m {
+			return nil, fmt.Errorf("cluster: distance matrix row %d has %d columns, want %d", i, len(D[i]), m)
+		}
+		if D[i][i] != 0 {
+			return nil, fmt.Errorf("cluster: distance matrix has non-zero diagonal at (%d, %d)", i, i)
+		}
+		for j := i + 1; j < m; j++ {
+			if math.IsNaN(D[i][j]) || math.IsNaN(D[j][i]) {
+				return nil, &NaNError{i, j}
+			}
+			if D[i][j] != D[j][i] {
+				return nil, fmt.Errorf("cluster: distance matrix is not symmetric at (%d, %d)", i, j)
+			}
+			v = append(v, D[i][j])
+		}
+	}
+	return newDistancesCondensed(v, m), nil
+}
+
+// NewDistancesFromCondensed returns the distances given in condensed form (see Distances).
+// The distances are not copied. Empty distances are taken to have no data points.
+func NewDistancesFromCondensed(v Vector) (*Distances, error) {
+	if len(v) == 0 {
+		return newDistancesCondensed(v, 0), nil
+	}
+	// solve m(m-1)/2 = len(v) for m
+	m := int(math.Round((1 + math.Sqrt(1 + 8 * float64(len(v)))) / 2))
+	if condensedLen(m) != len(v) {
+		return nil, fmt.Errorf("cluster: %d condensed distances do not match any number of data points", len(v))
+	}
+	return newDistancesCondensed(v, m), nil
+}
+
+// newDistancesCondensed returns the condensed distances v of m data points
+func newDistancesCondensed(v Vector, m int) *Distances {
+	return &Distances{ rep: v, m: m, index: mlgo.Range(0, m) }
+}
+
+// checkPoints returns an error if the condensed distances of m data points
+// with the specified size in bytes cannot be addressed without overflow
+func checkPoints(m, size int) error {
+	if m < 0 || m > 1 && (m - 1 > math.MaxInt / m || condensedLen(m) > (math.MaxInt - distanceHeader) / size) {
+		return fmt.Errorf("cluster: invalid number of data points %d", m)
+	}
+	return nil
+}
+
+// WriteDistancesCSV writes the distances as a full matrix in CSV format.
+func WriteDistancesCSV(w io.Writer, d DistanceProvider) error {
+	m := d.Len()
+	D := make(Matrix, m)
+	for i := range D {
+		D[i] = make(Vector, m)
+		for j := range D[i] {
+			D[i][j] = d.Get(i, j)
+		}
+	}
+	return WriteGridCSV(w, D)
+}
+
+// ReadDistancesCSV reads distances written as a full matrix in CSV format.
+func ReadDistancesCSV(r io.Reader) (*Distances, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	D := make(Matrix, len(records))
+	for i, record := range records {
+		D[i] = make(Vector, len(record))
+		for j, field := range record {
+			if D[i][j], err = strconv.ParseFloat(field, 64); err != nil {
+				return nil, fmt.Errorf("cluster: distance matrix row %d: %v", i, err)
+			}
+		}
+	}
+	return NewDistancesFromMatrix(D)
+}
+
+// WritePHYLIP writes the distances in the lower-triangular PHYLIP format:
+// the number of data points on the first line, followed by one line for each
+// data point with its name and its distances to the preceding data points.
+// Data points are named by their index if names is nil; returns an error if
+// there are fewer names than data points.
+func WritePHYLIP(w io.Writer, d DistanceProvider, names []string) error {
+	m := d.Len()
+	if names != nil && len(names) < m {
+		return fmt.Errorf("cluster: %d names for %d data points", len(names), m)
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d\n", m)
+	for i := 0; i < m; i++ {
+		name := strconv.Itoa(i)
+		if names != nil {
+			name = names[i]
+		}
+		bw.WriteString(name)
+		for j := 0; j < i; j++ {
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(d.Get(i, j), 'g', -1, 64))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadPHYLIP reads distances in the lower-triangular PHYLIP format, as written
+// by WritePHYLIP, and returns the names of the data points.
+// Fields are delimited by whitespace, and rows may span several lines.
+func ReadPHYLIP(r io.Reader) (d *Distances, names []string, err error) {
+	s := bufio.NewScanner(r)
+	s.Split(bufio.ScanWords)
+	next := func() (string, error) {
+		if s.Scan() {
+			return s.Text(), nil
+		}
+		if err := s.Err(); err != nil {
+			return "", err
+		}
+		return "", io.ErrUnexpectedEOF
+	}
+
+	token, err := next()
+	if err != nil {
+		return
+	}
+	m, err := strconv.Atoi(token)
+	if err != nil || checkPoints(m, 8) != nil {
+		return nil, nil, fmt.Errorf("cluster: invalid number of data points %q", token)
+	}
+
+	// the rows are appended as they are read, s.t. memory is only allocated
+	// for data points that are present in the input
+	var lower Matrix
+	for i := 0; i < m; i++ {
+		name, err := next()
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+		row := make(Vector, 0)
+		for j := 0; j < i; j++ {
+			if token, err = next(); err != nil {
+				return nil, nil, err
+			}
+			x, err := strconv.ParseFloat(token, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cluster: distance of %s: %v", name, err)
+			}
+			row = append(row, x)
+		}
+		lower = append(lower, row)
+	}
+
+	// reorder to the condensed form of the upper triangle
+	v := make(Vector, 0, condensedLen(m))
+	for i := 0; i < m; i++ {
+		for j := i + 1; j < m; j++ {
+			v = append(v, lower[j][i])
+		}
+	}
+	return newDistancesCondensed(v, m), names, nil
+}
+
+// WriteDistances writes the distances in the binary format of distance files
+// (see CreateMappedDistances), in single precision if d is stored so.
+func WriteDistances(w io.Writer, d DistanceProvider) error {
+	size := 8
+	if dd, ok := d.(*Distances); ok && dd.rep32 != nil {
+		size = 4
+	}
+	bw := bufio.NewWriter(w)
+	bw.Write(newDistanceHeader(d.Len(), size))
+	b := make([]byte, size)
+	m := d.Len()
+	for i := 0; i < m; i++ {
+		for j := i + 1; j < m; j++ {
+			if size == 4 {
+				binary.LittleEndian.PutUint32(b, math.Float32bits(float32(d.Get(i, j))))
+			} else {
+				binary.LittleEndian.PutUint64(b, math.Float64bits(d.Get(i, j)))
+			}
+			bw.Write(b)
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadDistances reads distances in the binary format of distance files into memory.
+// Returns an error if the input holds fewer distances than its header specifies.
+func ReadDistances(r io.Reader) (*Distances, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, distanceHeader)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	m, size, err := parseDistanceHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	// the distances are appended as they are read, s.t. memory is only
+	// allocated for distances that are present in the input
+	d := &Distances{ m: m, index: mlgo.Range(0, m) }
+	n := condensedLen(m)
+	if size == 4 {
+		d.rep32 = make([]float32, 0)
+	} else {
+		d.rep = make(Vector, 0)
+	}
+	b := make([]byte, size)
+	for k := 0; k < n; k++ {
+		if _, err = io.ReadFull(br, b); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if size == 4 {
+			d.rep32 = append(d.rep32, math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		} else {
+			d.rep = append(d.rep, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+	}
+	return d, nil
+}
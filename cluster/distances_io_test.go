@@ -0,0 +1,185 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+func TestNewDistancesFromMatrix(t *testing.T) {
+	want := NewDistances(distancesX, Euclidean)
+	D := make(Matrix, len(distancesX))
+	for i := range D {
+		D[i] = make(Vector, len(distancesX))
+		for j := range D[i] {
+			D[i][j] = want.Get(i, j)
+		}
+	}
+	d, err := NewDistancesFromMatrix(D)
+	if err != nil {
+		t.Fatalf("NewDistancesFromMatrix(...) returned error: %v", err)
+	}
+	if !mlgo.Vector(d.Condensed()).Equal(mlgo.Vector(want.Condensed())) {
+		t.Errorf("NewDistancesFromMatrix(...) got %v, want %v", d.Condensed(), want.Condensed())
+	}
+
+	invalid := []Matrix{
+		{{0, 1}, {2, 0}},
+		{{0, 1}, {1}},
+		{{0, 1}, {1, 2}},
+		{{0, math.NaN()}, {math.NaN(), 0}},
+	}
+	for i, D := range invalid {
+		if _, err := NewDistancesFromMatrix(D); err == nil {
+			t.Errorf("#%d NewDistancesFromMatrix(%v) returned no error", i, D)
+		}
+	}
+	if _, err := NewDistancesFromCondensed(Vector{1, 2}); err == nil {
+		t.Errorf("NewDistancesFromCondensed(...) of 2 distances returned no error")
+	}
+}
+
+func TestDistancesCSV(t *testing.T) {
+	want := NewDistances(distancesX, Euclidean)
+	var b bytes.Buffer
+	if err := WriteDistancesCSV(&b, want); err != nil {
+		t.Fatalf("WriteDistancesCSV(...) returned error: %v", err)
+	}
+	d, err := ReadDistancesCSV(&b)
+	if err != nil {
+		t.Fatalf("ReadDistancesCSV(...) returned error: %v", err)
+	}
+	if !mlgo.Vector(d.Condensed()).Equal(mlgo.Vector(want.Condensed())) {
+		t.Errorf("ReadDistancesCSV(...) got %v, want %v", d.Condensed(), want.Condensed())
+	}
+}
+
+func TestDistancesPHYLIP(t *testing.T) {
+	// the row of d may span two lines
+	input := `4
+a
+b 1
+c 2 3
+d 4 5
+  6
+`
+	d, names, err := ReadPHYLIP(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadPHYLIP(...) returned error: %v", err)
+	}
+	want := Vector{1, 2, 4, 3, 5, 6}
+	if !mlgo.Vector(d.Condensed()).Equal(mlgo.Vector(want)) {
+		t.Errorf("ReadPHYLIP(...) got %v, want %v", d.Condensed(), want)
+	}
+	if strings.Join(names, "") != "abcd" {
+		t.Errorf("ReadPHYLIP(...) got names %v, want [a b c d]", names)
+	}
+
+	var b bytes.Buffer
+	if err := WritePHYLIP(&b, d, names); err != nil {
+		t.Fatalf("WritePHYLIP(...) returned error: %v", err)
+	}
+	if got, want := b.String(), "4\na\nb 1\nc 2 3\nd 4 5 6\n"; got != want {
+		t.Errorf("WritePHYLIP(...) got %q, want %q", got, want)
+	}
+
+	if _, _, err := ReadPHYLIP(strings.NewReader("3\na\nb 1\n")); err == nil {
+		t.Errorf("ReadPHYLIP(...) of truncated input returned no error")
+	}
+}
+
+func TestDistancesBinary(t *testing.T) {
+	for _, want := range []*Distances{NewDistances(distancesX, Euclidean), NewDistances32(distancesX, Euclidean)} {
+		var b bytes.Buffer
+		if err := WriteDistances(&b, want); err != nil {
+			t.Fatalf("WriteDistances(...) returned error: %v", err)
+		}
+		d, err := ReadDistances(&b)
+		if err != nil {
+			t.Fatalf("ReadDistances(...) returned error: %v", err)
+		}
+		if (d.rep32 != nil) != (want.rep32 != nil) {
+			t.Errorf("ReadDistances(...) did not preserve the precision")
+		}
+		if !mlgo.Vector(d.Condensed()).Equal(mlgo.Vector(want.Condensed())) {
+			t.Errorf("ReadDistances(...) got %v, want %v", d.Condensed(), want.Condensed())
+		}
+	}
+}
+
+func TestDistancesWithoutCoordinates(t *testing.T) {
+	test := pamTests[1]
+	d, err := NewDistancesFromCondensed(NewDistances(test.x, test.metric).Condensed())
+	if err != nil {
+		t.Fatalf("NewDistancesFromCondensed(...) returned error: %v", err)
+	}
+
+	c := NewKMedoids(nil, nil, d)
+	c.Algorithm = PAM
+	if classes := c.Cluster(test.k); classes == nil || !classes.Index.Equal(test.index) {
+		t.Errorf("KMedoids.Cluster(%d) without coordinates got %v, want %v", test.k, classes, test.index)
+	}
+
+	h, err := NewHierarchical(nil, nil, AverageLinkage, d)
+	if err != nil {
+		t.Fatalf("NewHierarchical(...) returned error: %v", err)
+	}
+	if classes := h.Cluster(test.k); !classes.Index.Equal(test.index) {
+		t.Errorf("Hierarchical.Cluster(%d) without coordinates got %v, want %v", test.k, classes.Index, test.index)
+	}
+}
+
+func TestDistancesEmpty(t *testing.T) {
+	d, err := ReadDistancesCSV(strings.NewReader(""))
+	if err != nil || d.Len() != 0 {
+		t.Errorf("ReadDistancesCSV(empty) got %v, %v, want no data points", d, err)
+	}
+	if d, _ = NewDistancesFromCondensed(Vector{}); d.Len() != 0 {
+		t.Errorf("NewDistancesFromCondensed(empty).Len() got %d, want 0", d.Len())
+	}
+	for _, m := range []int{0, 1} {
+		input := "0\n"
+		if m == 1 {
+			input = "1\na\n"
+		}
+		d, names, err := ReadPHYLIP(strings.NewReader(input))
+		if err != nil || d.Len() != m || len(names) != m {
+			t.Errorf("ReadPHYLIP(%q) got %v, %v, %v, want %d data points", input, d, names, err, m)
+		}
+		var b bytes.Buffer
+		WriteDistances(&b, d)
+		if d, err := ReadDistances(&b); err != nil || d.Len() != m {
+			t.Errorf("ReadDistances(...) of %d data points got %v, %v", m, d, err)
+		}
+	}
+}
+
+func TestDistancesInvalidSize(t *testing.T) {
+	// headers whose number of data points exceed the input or overflow
+	for _, m := range []uint64{5, 1 << 40, 1 << 62, math.MaxUint64} {
+		b := newDistanceHeader(0, 8)
+		binary.LittleEndian.PutUint64(b[8:], m)
+		b = append(b, make([]byte, 3 * 8)...)
+		if _, err := ReadDistances(bytes.NewReader(b)); err == nil {
+			t.Errorf("ReadDistances(...) with %d data points in the header returned no error", m)
+		}
+	}
+	b := append(newDistanceHeader(3, 8), make([]byte, 2 * 8)...)
+	if _, err := ReadDistances(bytes.NewReader(b)); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadDistances(...) of truncated input got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	for _, input := range []string{"-1\n", "4294967296\n", "9223372036854775807\n", "1000000000\na\n"} {
+		if _, _, err := ReadPHYLIP(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadPHYLIP(%q) returned no error", input)
+		}
+	}
+
+	d, _ := NewDistancesFromCondensed(Vector{1, 2, 3})
+	if err := WritePHYLIP(io.Discard, d, []string{"a", "b"}); err == nil {
+		t.Errorf("WritePHYLIP(...) with 2 names for 3 data points returned no error")
+	}
+}
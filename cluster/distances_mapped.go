@@ -0,0 +1,239 @@
+package cluster
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"github.com/NullHypothesis/mlgo"
+)
+
+// Distance files hold the condensed distances (see Distances) after a header
+// of 24 bytes: the magic string "mlgodist", the number of data points and the
+// size of each distance in bytes (4 or 8), as little-endian uint64.
+// The distances are stored as little-endian IEEE 754 floating-point numbers.
+const (
+	distanceMagic = "mlgodist"
+	distanceHeader = 24
+)
+
+// ErrDistanceFile is returned for files that are not valid distance files.
+var ErrDistanceFile = errors.New("cluster: invalid distance file")
+
+// ErrSubsetClose is returned by Close for subsets of mapped distances.
+var ErrSubsetClose = errors.New("cluster: subsets of mapped distances cannot be closed")
+
+// ErrReadOnly is returned by Set for distance files opened by OpenMappedDistances.
+var ErrReadOnly = errors.New("cluster: distance file is mapped read-only")
+
+// MappedDistances provides the distances stored in a memory-mapped distance file,
+// s.t. the distance matrix need not fit into memory.
+type MappedDistances struct {
+	file *mappedFile
+	index []int
+	// whether d is a subset, which shares the mapping of its parent
+	subset bool
+}
+
+// mappedFile is a memory-mapped distance file, shared by subsets
+type mappedFile struct {
+	f *os.File
+	data []byte
+	// number of data points
+	m int
+	// size of each distance in bytes
+	size int
+	// whether the mapping may be written
+	writable bool
+}
+
+// CreateMappedDistances creates a distance file for m data points, with
+// distances in single precision if single is set, and maps it into memory.
+// The distances are zero until set by Set.
+func CreateMappedDistances(path string, m int, single bool) (*MappedDistances, error) {
+	size := 8
+	if single {
+		size = 4
+	}
+	if err := checkPoints(m, size); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR | os.O_CREATE | os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Write(newDistanceHeader(m, size)); err == nil {
+		err = f.Truncate(int64(distanceHeader + condensedLen(m) * size))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return mapDistances(f, m, size, true)
+}
+
+// OpenMappedDistances maps an existing distance file into memory for reading.
+func OpenMappedDistances(path string) (*MappedDistances, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	m, size, err := readDistanceHeader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return mapDistances(f, m, size, false)
+}
+
+// BuildMappedDistances creates a distance file with the distances between the
+// data points of X, calculated concurrently in tiles as by NewDistancesWith.
+func BuildMappedDistances(path string, X Matrix, metric MetricOp, single bool) (*MappedDistances, error) {
+	m := len(X)
+	d, err := CreateMappedDistances(path, m, single)
+	if err != nil {
+		return nil, err
+	}
+	tiles := distanceTiles(m)
+	parallelQueue(len(tiles), 0, func(t int) {
+		i0, j0 := tiles[t][0], tiles[t][1]
+		iEnd, jEnd := i0 + distanceBlock, j0 + distanceBlock
+		if iEnd > m { iEnd = m }
+		if jEnd > m { jEnd = m }
+		for i := i0; i < iEnd; i++ {
+			j := j0
+			if j <= i {
+				j = i + 1
+			}
+			for ; j < jEnd; j++ {
+				d.file.set(i, j, metric(X[i], X[j]))
+			}
+		}
+	})
+	return d, nil
+}
+
+func mapDistances(f *os.File, m, size int, writable bool) (*MappedDistances, error) {
+	n := distanceHeader + condensedLen(m) * size
+	if info, err := f.Stat(); err != nil {
+		f.Close()
+		return nil, err
+	} else if info.Size() < int64(n) {
+		f.Close()
+		return nil, fmt.Errorf("%w: %d bytes, want %d", ErrDistanceFile, info.Size(), n)
+	}
+	data, err := mapFile(f, n, writable)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	file := &mappedFile{ f: f, data: data, m: m, size: size, writable: writable }
+	return &MappedDistances{ file: file, index: mlgo.Range(0, m) }, nil
+}
+
+// readDistanceHeader reads the number of data points and the size of each distance
+func readDistanceHeader(f *os.File) (m, size int, err error) {
+	header := make([]byte, distanceHeader)
+	if _, err = f.ReadAt(header, 0); err != nil {
+		return
+	}
+	return parseDistanceHeader(header)
+}
+
+// newDistanceHeader returns the header of a distance file
+func newDistanceHeader(m, size int) []byte {
+	header := make([]byte, distanceHeader)
+	copy(header, distanceMagic)
+	binary.LittleEndian.PutUint64(header[8:], uint64(m))
+	binary.LittleEndian.PutUint64(header[16:], uint64(size))
+	return header
+}
+
+// parseDistanceHeader returns the number of data points and the size of each distance
+func parseDistanceHeader(header []byte) (m, size int, err error) {
+	if string(header[:8]) != distanceMagic {
+		err = ErrDistanceFile
+		return
+	}
+	n := binary.LittleEndian.Uint64(header[8:])
+	size = int(binary.LittleEndian.Uint64(header[16:]))
+	if size != 4 && size != 8 {
+		err = fmt.Errorf("%w: distance size %d", ErrDistanceFile, size)
+		return
+	}
+	if n > math.MaxInt || checkPoints(int(n), size) != nil {
+		err = fmt.Errorf("%w: %d data points", ErrDistanceFile, n)
+		return
+	}
+	m = int(n)
+	return
+}
+
+func (d *MappedDistances) Len() int {
+	return len(d.index)
+}
+
+// Subset returns the distances between the subset of data points specified by index,
+// which indexes the data points of d. The subset shares the mapping of d.
+func (d *MappedDistances) Subset(index []int) DistanceProvider {
+	return &MappedDistances{ file: d.file, index: Permute(d.index, index), subset: true }
+}
+
+func (d *MappedDistances) Get(i, j int) float64 {
+	a, b := d.index[i], d.index[j]
+	if a == b {
+		return 0
+	}
+	p := d.file.data[d.file.offset(a, b):]
+	if d.file.size == 4 {
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(p)))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(p))
+}
+
+// Set sets the distance between data points i and j, i != j, of a file created
+// by CreateMappedDistances. Distances of different pairs may be set concurrently.
+// Returns ErrReadOnly if the file was opened by OpenMappedDistances.
+func (d *MappedDistances) Set(i, j int, x float64) error {
+	if !d.file.writable {
+		return ErrReadOnly
+	}
+	a, b := d.index[i], d.index[j]
+	if a == b {
+		return fmt.Errorf("cluster: cannot set the distance of data point %d to itself", i)
+	}
+	d.file.set(a, b, x)
+	return nil
+}
+
+// Close unmaps the distance file and closes it; the distances of d and its
+// subsets must not be used afterwards. Returns ErrSubsetClose for subsets,
+// which share the mapping: only the distances returned by CreateMappedDistances
+// or OpenMappedDistances may be closed.
+func (d *MappedDistances) Close() error {
+	if d.subset {
+		return ErrSubsetClose
+	}
+	err := unmapFile(d.file.data)
+	if e := d.file.f.Close(); err == nil {
+		err = e
+	}
+	d.file.data = nil
+	return err
+}
+
+// set stores the distance between data points a and b, a != b
+func (f *mappedFile) set(a, b int, x float64) {
+	p := f.data[f.offset(a, b):]
+	if f.size == 4 {
+		binary.LittleEndian.PutUint32(p, math.Float32bits(float32(x)))
+	} else {
+		binary.LittleEndian.PutUint64(p, math.Float64bits(x))
+	}
+}
+
+// offset returns the position of the distance between data points a and b in the file
+func (f *mappedFile) offset(a, b int) int {
+	return distanceHeader + condensedIndex(f.m, a, b) * f.size
+}
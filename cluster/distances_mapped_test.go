@@ -0,0 +1,122 @@
+//go:build unix
+
+package cluster
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMappedDistances(t *testing.T) {
+	x := pamTests[1].x
+	path := filepath.Join(t.TempDir(), "distances")
+	want := NewDistances(x, Euclidean)
+
+	for _, single := range []bool{false, true} {
+		d, err := BuildMappedDistances(path, x, Euclidean, single)
+		if err != nil {
+			t.Fatalf("BuildMappedDistances(...) returned error: %v", err)
+		}
+		if err = d.Close(); err != nil {
+			t.Fatalf("MappedDistances.Close() returned error: %v", err)
+		}
+
+		d, err = OpenMappedDistances(path)
+		if err != nil {
+			t.Fatalf("OpenMappedDistances(...) returned error: %v", err)
+		}
+		if d.Len() != len(x) {
+			t.Errorf("OpenMappedDistances(...).Len() got %d, want %d", d.Len(), len(x))
+		}
+		for i := range x {
+			for j := range x {
+				if got := d.Get(i, j); float32Round(got, single) != float32Round(want.Get(i, j), single) {
+					t.Errorf("MappedDistances.Get(%d, %d) got %v, want %v", i, j, got, want.Get(i, j))
+				}
+			}
+		}
+		index := []int{11, 0, 5}
+		if err := d.Subset(index).(*MappedDistances).Close(); err != ErrSubsetClose {
+			t.Errorf("MappedDistances.Subset(...).Close() got error %v, want %v", err, ErrSubsetClose)
+		}
+		if got, w := d.Subset(index).Get(0, 2), want.Subset(index).Get(0, 2); float32Round(got, single) != float32Round(w, single) {
+			t.Errorf("MappedDistances.Subset(...).Get(0, 2) got %v, want %v", got, w)
+		}
+
+		// distance-based algorithms run on the mapped distances
+		c := NewKMedoids(x, Euclidean, d)
+		c.Algorithm = PAM
+		if classes := c.Cluster(3); !classes.Index.Equal(pamTests[1].index) {
+			t.Errorf("KMedoids.Cluster(3) on MappedDistances got %v, want %v", classes.Index, pamTests[1].index)
+		}
+		d.Close()
+	}
+}
+
+func float32Round(x float64, round bool) float64 {
+	if round {
+		return float64(float32(x))
+	}
+	return x
+}
+
+func TestOpenMappedDistancesInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid")
+	if err := os.WriteFile(path, []byte("not a distance file at all"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenMappedDistances(path); !errors.Is(err, ErrDistanceFile) {
+		t.Errorf("OpenMappedDistances(...) got error %v, want %v", err, ErrDistanceFile)
+	}
+}
+
+func TestBuildMappedDistancesTiles(t *testing.T) {
+	// more data points than a block, s.t. the distances are split into tiles
+	x := make(Matrix, 2 * distanceBlock + 3)
+	for i := range x {
+		x[i] = Vector{float64(i), float64(i * i % 7)}
+	}
+	want := NewDistances(x, Euclidean)
+	path := filepath.Join(t.TempDir(), "distances")
+	d, err := BuildMappedDistances(path, x, Euclidean, false)
+	if err != nil {
+		t.Fatalf("BuildMappedDistances(...) returned error: %v", err)
+	}
+	defer d.Close()
+	for i := range x {
+		for j := range x {
+			if got := d.Get(i, j); got != want.Get(i, j) {
+				t.Fatalf("BuildMappedDistances(...).Get(%d, %d) got %v, want %v", i, j, got, want.Get(i, j))
+			}
+		}
+	}
+}
+
+func TestMappedDistancesSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "distances")
+	d, err := CreateMappedDistances(path, 3, false)
+	if err != nil {
+		t.Fatalf("CreateMappedDistances(...) returned error: %v", err)
+	}
+	if err = d.Set(2, 0, 1.5); err != nil || d.Get(0, 2) != 1.5 {
+		t.Errorf("MappedDistances.Set(2, 0, 1.5) got %v, %v, want 1.5, nil", d.Get(0, 2), err)
+	}
+	if err = d.Set(1, 1, 1); err == nil {
+		t.Errorf("MappedDistances.Set(1, 1, 1) got no error")
+	}
+	d.Close()
+
+	// setting distances of a read-only mapping would fault
+	if d, err = OpenMappedDistances(path); err != nil {
+		t.Fatalf("OpenMappedDistances(...) returned error: %v", err)
+	}
+	defer d.Close()
+	if err = d.Set(0, 1, 2); err != ErrReadOnly {
+		t.Errorf("MappedDistances.Set(0, 1, 2) on a read-only file got error %v, want %v", err, ErrReadOnly)
+	}
+	if d.Get(0, 2) != 1.5 || d.Get(0, 1) != 0 {
+		t.Errorf("OpenMappedDistances(...) got distances %v, %v, want 1.5, 0", d.Get(0, 2), d.Get(0, 1))
+	}
+}
@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+var distancesX = Matrix{{0, 0}, {3, 4}, {6, 8}, {0, 1}}
+
+func TestDistancesCondensed(t *testing.T) {
+	d := NewDistances(distancesX, Euclidean)
+	// pdist order: (0,1), (0,2), (0,3), (1,2), (1,3), (2,3)
+	want := Vector{5, 10, 1, 5, math.Sqrt(18), math.Sqrt(85)}
+	if got := d.Condensed(); !mlgo.Vector(got).Equal(mlgo.Vector(want)) {
+		t.Errorf("NewDistances(...).Condensed() got %v, want %v", got, want)
+	}
+	if len(d.rep) != 6 {
+		t.Errorf("NewDistances(...) stores %d distances, want 6", len(d.rep))
+	}
+}
+
+func TestDistancesGet(t *testing.T) {
+	for _, d := range []*Distances{NewDistances(distancesX, Euclidean), NewDistances32(distancesX, Euclidean)} {
+		for i := range distancesX {
+			for j := range distancesX {
+				want := Euclidean(distancesX[i], distancesX[j])
+				if got := d.Get(i, j); math.Abs(got - want) > 1e-6 {
+					t.Errorf("Distances.Get(%d, %d) got %v, want %v", i, j, got, want)
+				}
+			}
+		}
+
+		// subset in reverse order
+		s := d.Subset([]int{3, 2, 1})
+		if s.Len() != 3 {
+			t.Errorf("Distances.Subset(...).Len() got %d, want 3", s.Len())
+		}
+		if got, want := s.Get(0, 1), Euclidean(distancesX[3], distancesX[2]); math.Abs(got - want) > 1e-6 {
+			t.Errorf("Distances.Subset(...).Get(0, 1) got %v, want %v", got, want)
+		}
+		if got, want := s.Subset([]int{2, 0}).Get(0, 1), Euclidean(distancesX[1], distancesX[3]); math.Abs(got - want) > 1e-6 {
+			t.Errorf("Distances.Subset(...).Subset(...).Get(0, 1) got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestNewDistancesWith(t *testing.T) {
+	// more data points than a block, s.t. the distances are split into tiles
+	x := make(Matrix, 3 * distanceBlock + 5)
+	for i := range x {
+		x[i] = Vector{float64(i % 17), float64(i * i % 23)}
+	}
+	m := len(x)
+	want := make(Vector, 0, m * (m - 1) / 2)
+	for i := 0; i < m; i++ {
+		for j := i + 1; j < m; j++ {
+			want = append(want, Euclidean(x[i], x[j]))
+		}
+	}
+
+	for _, workers := range []int{1, 3, 0} {
+		last, calls := 0, 0
+		opts := DistancesOptions{
+			Workers: workers,
+			Progress: func(done, total int) {
+				if done <= last || total != len(want) {
+					t.Errorf("DistancesOptions.Progress(%d, %d) after %d, want increasing up to %d", done, total, last, len(want))
+				}
+				last = done
+				calls++
+			},
+		}
+		d := NewDistancesWith(x, Euclidean, opts)
+		if !mlgo.Vector(d.rep).Equal(mlgo.Vector(want)) {
+			t.Errorf("NewDistancesWith(...) with %d workers got wrong distances", workers)
+		}
+		// 4 blocks: 10 tiles of the upper triangle
+		if last != len(want) || calls != 10 {
+			t.Errorf("NewDistancesWith(...) with %d workers reported %d of %d distances in %d calls, want %d in 10 calls", workers, last, len(want), calls, len(want))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package cluster
+
+// EmptyClusterStrategy specifies how KMeans and KMedians recover
+// when a center loses all its members during expectation.
+type EmptyClusterStrategy int
+
+const (
+	// re-seed the empty cluster with the data point that has the largest
+	// distance to its center
+	ReseedFarthest EmptyClusterStrategy = iota
+	// split the largest cluster, using its member farthest from the center
+	// as the seed of the empty cluster
+	SplitLargest
+	// drop the empty cluster; the number of clusters is reduced
+	DropEmpty
+)
+
+// sizes returns the number of members of each cluster.
+func (c *KMeans) sizes() []int {
+	sizes := make([]int, len(c.Centers))
+	for _, class := range c.Clusters {
+		sizes[class]++
+	}
+	return sizes
+}
+
+// hasEmpty returns whether any cluster has no members.
+func (c *KMeans) hasEmpty() bool {
+	for _, n := range c.sizes() {
+		if n == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// recoverEmpty applies the empty cluster strategy to all clusters without members,
+// s.t. every cluster has at least one member before maximization.
+func (c *KMeans) recoverEmpty() {
+	sizes := c.sizes()
+	for ii := 0; ii < len(c.Centers); ii++ {
+		if sizes[ii] > 0 {
+			continue
+		}
+
+		if c.EmptyClusters == DropEmpty {
+			c.drop(ii)
+			sizes = append(sizes[:ii], sizes[ii+1:]...)
+			c.Dropped++
+			// cluster ii+1 has moved to position ii
+			ii--
+			continue
+		}
+
+		// find the data point to re-seed from, among clusters that can spare a member
+		largest := -1
+		if c.EmptyClusters == SplitLargest {
+			largest = 0
+			for jj, n := range sizes {
+				if n > sizes[largest] {
+					largest = jj
+				}
+			}
+		}
+		seed, max := -1, -1.0
+		for i, class := range c.Clusters {
+			if sizes[class] < 2 || (largest >= 0 && class != largest) {
+				continue
+			}
+			if d := c.Metric(c.X[c.Index[i]], c.Centers[class]); d > max {
+				seed, max = i, d
+			}
+		}
+		if seed < 0 {
+			// no cluster has more than one member: drop the cluster
+			c.drop(ii)
+			sizes = append(sizes[:ii], sizes[ii+1:]...)
+			c.Dropped++
+			ii--
+			continue
+		}
+
+		old := c.Clusters[seed]
+		c.setCenter(ii, c.X[c.Index[seed]])
+		c.Clusters[seed] = ii
+		sizes[old]--
+		sizes[ii]++
+
+		if largest >= 0 {
+			// split: move the members of the largest cluster that are nearer to the seed
+			for i, class := range c.Clusters {
+				if class != old || sizes[old] < 2 {
+					continue
+				}
+				x := c.X[c.Index[i]]
+				if c.Metric(x, c.Centers[ii]) < c.Metric(x, c.Centers[old]) {
+					c.Clusters[i] = ii
+					sizes[old]--
+					sizes[ii]++
+				}
+			}
+		}
+	}
+	c.K = len(c.Centers)
+}
+
+// drop removes cluster ii and relabels the following clusters.
+func (c *KMeans) drop(ii int) {
+	c.Centers = append(c.Centers[:ii], c.Centers[ii+1:]...)
+	c.Errors = append(c.Errors[:ii], c.Errors[ii+1:]...)
+	for i, class := range c.Clusters {
+		if class > ii {
+			c.Clusters[i] = class - 1
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+)
+
+var emptyClusterTests = []struct {
+	strategy EmptyClusterStrategy
+	k int
+}{
+	{ReseedFarthest, 3},
+	{SplitLargest, 3},
+	{DropEmpty, 2},
+}
+
+func TestEmptyClusters(t *testing.T) {
+	x := Matrix{{0}, {1}, {2}, {10}, {11}, {12}, {13}}
+	// no data point is nearest to the last center
+	initial := Matrix{{0}, {10}, {100}}
+
+	for i, test := range emptyClusterTests {
+		for _, c := range []Clusterer{NewKMeans(x, Euclidean), NewKMedians(x, Euclidean)} {
+			var base *KMeans
+			switch c := c.(type) {
+				case *KMeans:
+					base = c
+				case *KMedians:
+					base = &c.KMeans
+			}
+			base.Seeding, base.InitialCenters = UserSeeding, initial
+			base.EmptyClusters = test.strategy
+
+			classes := c.Cluster(3)
+			if classes.K != test.k {
+				t.Errorf("#%d %T.Cluster(3) got K = %d, want %d", i, c, classes.K, test.k)
+			}
+			for kk, n := range classes.Sizes() {
+				if n == 0 {
+					t.Errorf("#%d %T.Cluster(3) got empty cluster %d: %v", i, c, kk, classes.Index)
+				}
+			}
+			for _, center := range base.Centers {
+				if math.IsNaN(center[0]) {
+					t.Errorf("#%d %T.Cluster(3) got centers %v", i, c, base.Centers)
+				}
+			}
+			if dropped := 3 - test.k; base.Dropped != dropped {
+				t.Errorf("#%d %T.Cluster(3) dropped %d clusters, want %d", i, c, base.Dropped, dropped)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors returned by the Fit methods (and ClusterContext) of the clusterers.
+// The Cluster methods return nil instead of an error.
+
+// ErrNoData is returned if there are no data points or distances to cluster.
+var ErrNoData = errors.New("cluster: no data")
+
+// DimensionError is returned if a vector does not have the dimension of the
+// first data point (or of the other argument of a metric).
+type DimensionError struct {
+	// Index of the vector
+	Index int
+	// Dimension of the vector
+	Len int
+	// Expected dimension
+	Want int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("cluster: vector %d has dimension %d, want %d", e.Index, e.Len, e.Want)
+}
+
+// KError is returned if the number of clusters is invalid for the number of data points.
+type KError struct {
+	K int
+	// Number of data points
+	Len int
+}
+
+func (e *KError) Error() string {
+	return fmt.Sprintf("cluster: invalid number of clusters %d for %d data points", e.K, e.Len)
+}
+
+// NaNError is returned if the input contains NaN.
+// Missing values can be replaced beforehand by mlgo.Matrix.Imputed.
+// For distances, Row and Col are the indices of the pair of data points.
+type NaNError struct {
+	Row, Col int
+}
+
+func (e *NaNError) Error() string {
+	return fmt.Sprintf("cluster: NaN at [%d, %d]", e.Row, e.Col)
+}
+
+// EmptyClusterError is returned along with the classification information
+// if some clusters have no members after clustering.
+type EmptyClusterError struct {
+	// Indices of the empty clusters
+	Clusters []int
+}
+
+func (e *EmptyClusterError) Error() string {
+	return fmt.Sprintf("cluster: empty clusters %v", e.Clusters)
+}
+
+// Distance returns the distance between x and y by metric, or an error
+// if x and y have different dimensions or contain NaN.
+// The index of x is 0, and the index of y is 1.
+func Distance(metric MetricOp, x, y Vector) (float64, error) {
+	if len(x) != len(y) {
+		return 0, &DimensionError{1, len(y), len(x)}
+	}
+	for i, v := range []Vector{x, y} {
+		if err := checkVector(v, i); err != nil {
+			return 0, err
+		}
+	}
+	return metric(x, y), nil
+}
+
+// checkData returns an error if the data points of X specified by index
+// (all data points if index is nil) are missing, differ in dimension or contain NaN.
+func checkData(X Matrix, index []int) error {
+	if index == nil {
+		index = make([]int, len(X))
+		for i := range index {
+			index[i] = i
+		}
+	}
+	if len(index) == 0 {
+		return ErrNoData
+	}
+	n := len(X[index[0]])
+	for _, i := range index {
+		if len(X[i]) != n {
+			return &DimensionError{i, len(X[i]), n}
+		}
+		if err := checkVector(X[i], i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkVector returns an error if x contains NaN; i is the index of x.
+func checkVector(x Vector, i int) error {
+	for j, v := range x {
+		if math.IsNaN(v) {
+			return &NaNError{i, j}
+		}
+	}
+	return nil
+}
+
+// checkDistances returns an error if D is missing or contains NaN.
+func checkDistances(D DistanceProvider) error {
+	if D == nil || D.Len() == 0 {
+		return ErrNoData
+	}
+	m := D.Len()
+	for i := 0; i < m; i++ {
+		for j := i+1; j < m; j++ {
+			if math.IsNaN(D.Get(i, j)) {
+				return &NaNError{i, j}
+			}
+		}
+	}
+	return nil
+}
+
+// checkK returns an error unless 1 <= k <= max for m data points.
+func checkK(k, max, m int) error {
+	if k < 1 || k > max {
+		return &KError{k, m}
+	}
+	return nil
+}
+
+// checkEmpty returns an error if some of the classes have no members.
+func checkEmpty(classes *Classes) error {
+	var empty []int
+	for ii, n := range classes.Sizes() {
+		if n == 0 {
+			empty = append(empty, ii)
+		}
+	}
+	if empty != nil {
+		return &EmptyClusterError{empty}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+var (
+	_ Fitter = (*KMeans)(nil)
+	_ Fitter = (*KMedians)(nil)
+	_ Fitter = (*KMedoids)(nil)
+	_ Fitter = (*KMeansElkan)(nil)
+	_ Fitter = (*KMeansHamerly)(nil)
+	_ Fitter = (*MiniBatchKMeans)(nil)
+	_ Fitter = (*CLARA)(nil)
+	_ Fitter = (*CLARANS)(nil)
+	_ Fitter = (*MixModel)(nil)
+	_ Fitter = (*SOM)(nil)
+	_ Fitter = (*HClustersGeneric)(nil)
+	_ Fitter = (*HClustersNNChain)(nil)
+	_ Fitter = (*HClustersSingle)(nil)
+	_ Fitter = (*Hopach)(nil)
+)
+
+var fitErrorTests = []struct {
+	x Matrix
+	k int
+	err error
+}{
+	{nil, 2, ErrNoData},
+	{Matrix{{1, 2}, {2, 3}, {8, 9}}, 3, &KError{3, 3}},
+	{Matrix{{1, 2}, {2, 3}, {8, 9}}, 0, &KError{0, 3}},
+	{Matrix{{1, 2}, {2}, {8, 9}}, 2, &DimensionError{1, 1, 2}},
+	{Matrix{{1, 2}, {2, 3}, {8, math.NaN()}}, 2, &NaNError{2, 1}},
+	{Matrix{{1, 2}, {2, 3}, {8, 9}}, 2, nil},
+}
+
+func TestFitErrors(t *testing.T) {
+	for i, test := range fitErrorTests {
+		fitters := []Fitter{
+			NewKMeans(test.x, Euclidean),
+			NewKMedians(test.x, Manhattan),
+			NewKMedoids(test.x, Euclidean, nil),
+			NewKMeansElkan(test.x, Euclidean),
+			NewCLARA(test.x, Euclidean),
+		}
+		for _, c := range fitters {
+			classes, err := c.Fit(test.k)
+			if !reflect.DeepEqual(err, test.err) {
+				t.Errorf("#%d %T.Fit(%d) got error %v, want %v", i, c, test.k, err, test.err)
+			}
+			if (classes == nil) != (test.err != nil) {
+				t.Errorf("#%d %T.Fit(%d) got %v with error %v", i, c, test.k, classes, err)
+			}
+			// the Cluster wrappers return nil instead of the error
+			if test.err != nil && c.(Clusterer).Cluster(test.k) != nil {
+				t.Errorf("#%d %T.Cluster(%d) got classes, want nil", i, c, test.k)
+			}
+		}
+	}
+}
+
+func TestFitErrorsAs(t *testing.T) {
+	_, err := NewKMeans(Matrix{{1, 2}, {2, 3}, {8, math.NaN()}}, Euclidean).Fit(2)
+	var e *NaNError
+	if !errors.As(err, &e) || e.Row != 2 {
+		t.Errorf("KMeans.Fit(2) with NaN got error %v, want *NaNError", err)
+	}
+
+	_, err = NewKMedoids(nil, Euclidean, nil).Fit(2)
+	if !errors.Is(err, ErrNoData) {
+		t.Errorf("KMedoids.Fit(2) without data got error %v, want %v", err, ErrNoData)
+	}
+
+	h := &HClustersGeneric{}
+	if classes, err := h.Fit(2); classes != nil || !errors.Is(err, ErrNoData) {
+		t.Errorf("HClustersGeneric.Fit(2) without distances got %v, %v, want nil, %v", classes, err, ErrNoData)
+	}
+	h.D, _ = NewDistancesFromCondensed(Vector{1, math.NaN(), 2})
+	if _, err := h.Fit(2); !reflect.DeepEqual(err, &NaNError{0, 2}) {
+		t.Errorf("HClustersGeneric.Fit(2) with NaN distance got error %v, want %v", err, &NaNError{0, 2})
+	}
+	h.D, _ = NewDistancesFromCondensed(Vector{1, 5, 2})
+	if _, err := h.Fit(4); !reflect.DeepEqual(err, &KError{4, 3}) {
+		t.Errorf("HClustersGeneric.Fit(4) got error %v, want %v", err, &KError{4, 3})
+	}
+}
+
+func TestFitDimensionErrors(t *testing.T) {
+	// metrics return 0 for points of different dimensions: the checked API reports them
+	x := Matrix{{1, 2}, {2, 3}, {8, 9}}
+	c := NewKMeans(x, Euclidean)
+	c.Seeding, c.InitialCenters = UserSeeding, Matrix{{1, 2}, {8}}
+	if _, err := c.Fit(2); !reflect.DeepEqual(err, &DimensionError{1, 1, 2}) {
+		t.Errorf("KMeans.Fit(2) with initial center of dimension 1 got error %v, want %v", err, &DimensionError{1, 1, 2})
+	}
+
+	x = Matrix{{1, 2}, {2, 3}, {8}}
+	if _, err := NewHierarchical(x, Euclidean, AverageLinkage, nil); !reflect.DeepEqual(err, &DimensionError{2, 1, 2}) {
+		t.Errorf("NewHierarchical(...) got error %v, want %v", err, &DimensionError{2, 1, 2})
+	}
+	h := NewHClustersSingle(x, Euclidean, nil)
+	if _, err := h.Fit(2); !reflect.DeepEqual(err, &DimensionError{2, 1, 2}) {
+		t.Errorf("HClustersSingle.Fit(2) got error %v, want %v", err, &DimensionError{2, 1, 2})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if d, err := Distance(Euclidean, Vector{0, 0}, Vector{3, 4}); d != 5 || err != nil {
+		t.Errorf("Distance(Euclidean, ...) got %v, %v, want 5, nil", d, err)
+	}
+	want := &DimensionError{1, 1, 2}
+	if _, err := Distance(Euclidean, Vector{0, 0}, Vector{3}); !reflect.DeepEqual(err, want) {
+		t.Errorf("Distance(Euclidean, ...) got error %v, want %v", err, want)
+	}
+	if _, err := Distance(Euclidean, Vector{0, 0}, Vector{3, math.NaN()}); !reflect.DeepEqual(err, &NaNError{1, 1}) {
+		t.Errorf("Distance(Euclidean, ...) got error %v, want %v", err, &NaNError{1, 1})
+	}
+}
+
+func TestSilhouettesFit(t *testing.T) {
+	classes := &Classes{Partitions{0, 0, 1}, 2, 0, nil}
+	if s := Silhouettes(Matrix{}, &Classes{}); len(s) != 0 {
+		t.Errorf("Silhouettes(empty, ...) got %v, want empty", s)
+	}
+	if _, err := SilhouettesFit(Matrix{}, classes); err != ErrNoData {
+		t.Errorf("SilhouettesFit(empty, ...) got error %v, want %v", err, ErrNoData)
+	}
+	S := Matrix{{1, 4}, {1, 5}, {4}}
+	if _, err := SilhouettesFit(S, classes); !reflect.DeepEqual(err, &DimensionError{2, 1, 2}) {
+		t.Errorf("SilhouettesFit(...) got error %v, want %v", err, &DimensionError{2, 1, 2})
+	}
+	S[2] = Vector{4, 0}
+	s, err := SilhouettesFit(S, classes)
+	if err != nil || !reflect.DeepEqual(s, Silhouettes(S, classes)) {
+		t.Errorf("SilhouettesFit(...) got %v, %v, want %v", s, err, Silhouettes(S, classes))
+	}
+}
+
+func TestCheckEmpty(t *testing.T) {
+	classes := &Classes{Partitions{0, 0, 2}, 3, 0, nil}
+	want := &EmptyClusterError{[]int{1}}
+	if err := checkEmpty(classes); !reflect.DeepEqual(err, want) {
+		t.Errorf("checkEmpty(%v) got %v, want %v", classes.Index, err, want)
+	}
+}
@@ -1,15 +1,174 @@
 package cluster
 
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LinkageMethod specifies how the distance between clusters is updated
+// when two clusters are merged.
+type LinkageMethod int
+
 const (
-	single_linkage = iota
-	complete_linkage
-	average_linkage
-	mcquitty_linkage
-	median_linkage
-	centroid_linkage
-	ward_linkage
+	SingleLinkage LinkageMethod = iota
+	CompleteLinkage
+	AverageLinkage
+	McQuittyLinkage
+	MedianLinkage
+	CentroidLinkage
+	WardLinkage
 )
 
+// names of the linkage methods, following R's hclust
+var linkageNames = []string{
+	"single",
+	"complete",
+	"average",
+	"mcquitty",
+	"median",
+	"centroid",
+	"ward.D2",
+}
+
+func (method LinkageMethod) String() string {
+	if !method.Valid() {
+		return fmt.Sprintf("LinkageMethod(%d)", int(method))
+	}
+	return linkageNames[method]
+}
+
+// Valid returns whether method is one of the defined linkage methods.
+func (method LinkageMethod) Valid() bool {
+	return method >= SingleLinkage && method <= WardLinkage
+}
+
+// ParseLinkageMethod returns the linkage method with the specified name.
+// Names are case insensitive; "ward" is accepted as an alias of "ward.D2".
+func ParseLinkageMethod(name string) (LinkageMethod, error) {
+	name = strings.ToLower(name)
+	if name == "ward" {
+		return WardLinkage, nil
+	}
+	for i, s := range linkageNames {
+		if name == strings.ToLower(s) {
+			return LinkageMethod(i), nil
+		}
+	}
+	return 0, fmt.Errorf("cluster: unknown linkage method %q", name)
+}
+
+// reducible returns whether the linkage method satisfies the reducibility
+// property, s.t. the nearest-neighbour chain algorithm is applicable.
+func (method LinkageMethod) reducible() bool {
+	switch method {
+		case SingleLinkage, CompleteLinkage, AverageLinkage, McQuittyLinkage, WardLinkage:
+			return true
+	}
+	return false
+}
+
+// linkageDistances is a condensed working copy of the distances between clusters,
+// which is updated as clusters are merged
+type linkageDistances struct {
+	m int
+	rep Vector
+}
+
+func (d *linkageDistances) get(a, b int) float64 {
+	if a == b {
+		return 0
+	}
+	return d.rep[condensedIndex(d.m, a, b)]
+}
+
+func (d *linkageDistances) set(a, b int, v float64) {
+	d.rep[condensedIndex(d.m, a, b)] = v
+}
+
+// distances returns a working copy of the distances in D,
+// which is updated as clusters are merged
+// (the distances of the caller must be left intact).
+func (method LinkageMethod) distances(D DistanceProvider) (dist *linkageDistances) {
+	m := D.Len()
+	dist = &linkageDistances{ m: m, rep: make(Vector, condensedLen(m)) }
+	for i := 0; i < m; i++ {
+		for j := i+1; j < m; j++ {
+			d := D.Get(i, j)
+			if method == WardLinkage {
+				// Ward's update formula applies to squared distances
+				d *= d
+			}
+			dist.set(i, j, d)
+		}
+	}
+	return
+}
+
+// height converts a working distance to the height of a merge.
+func (method LinkageMethod) height(d float64) float64 {
+	if method == WardLinkage {
+		// distances were squared in the working copy
+		return math.Sqrt(d)
+	}
+	return d
+}
+
+// update returns the distance between cluster x and the cluster formed by
+// merging clusters a and b, using the Lance-Williams formula.
+func (method LinkageMethod) update(dAX, dBX, dAB, sizeA, sizeB, sizeX float64) (d float64) {
+	switch method {
+		case SingleLinkage:
+			d = math.Min(dAX, dBX)
+		case CompleteLinkage:
+			d = math.Max(dAX, dBX)
+		case AverageLinkage:
+			d = (sizeA * dAX + sizeB * dBX) / (sizeA + sizeB)
+		case McQuittyLinkage:
+			d = (dAX + dBX) / 2
+		case MedianLinkage:
+			d = (dAX + dBX) / 2 - dAB / 4
+		case CentroidLinkage:
+			size := sizeA + sizeB
+			d = (sizeA * dAX + sizeB * dBX) / size - sizeA * sizeB * dAB / (size * size)
+		case WardLinkage:
+			d = ((sizeA + sizeX) * dAX + (sizeB + sizeX) * dBX - sizeX * dAB) / (sizeA + sizeB + sizeX)
+	}
+	return
+}
+
+// Hierarchical is implemented by the hierarchical clustering algorithms.
+type Hierarchical interface {
+	Clusterer
+	Hierarchizer
+}
+
+// NewHierarchical returns a hierarchical clustering of the data points X
+// using the specified linkage method.
+// Single linkage uses the minimum spanning tree algorithm (HClustersSingle);
+// the other reducible methods (complete, average, McQuitty and Ward) use the
+// nearest-neighbour chain algorithm (HClustersNNChain);
+// median and centroid linkage use the generic algorithm (HClustersGeneric).
+// If d is nil, distances are calculated from X using metric.
+func NewHierarchical(X Matrix, metric MetricOp, method LinkageMethod, d DistanceProvider) (Hierarchical, error) {
+	if d == nil {
+		if err := checkData(X, nil); err != nil {
+			return nil, err
+		}
+	}
+	if method == SingleLinkage {
+		return NewHClustersSingle(X, metric, d), nil
+	}
+	if method.reducible() {
+		return NewHClustersNNChain(X, metric, method, d)
+	}
+	c, err := NewHClustersGeneric(X, metric, method, d)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 type HClusters struct {
 	// Data points [m x n]
 	X Matrix
@@ -18,9 +177,9 @@ type HClusters struct {
 	// number of clusters
 	K int
 	// linkage method
-	Method int
+	Method LinkageMethod
 	// Distances between data points [m x m]
-	D *Distances
+	D DistanceProvider
 	// Step-wise dendrogram
 	Dendrogram Linkages
 	// cluster center assignment index
@@ -31,17 +190,37 @@ type HClusters struct {
 	actives ActiveSet
 }
 
+func (c *HClusters) Len() int {
+	return c.D.Len()
+}
+
+// check returns an error if the distances are invalid, or if k is not in [1, m]
+func (c *HClusters) check(k int) error {
+	if c.X != nil {
+		// metrics do not report data points of different dimensions
+		if err := checkData(c.X, nil); err != nil {
+			return err
+		}
+	}
+	if err := checkDistances(c.D); err != nil {
+		return err
+	}
+	return checkK(k, c.Len(), c.Len())
+}
+
 // CutTree cuts the hierarchical cluster tree to generate K clusters.
 func (c *HClusters) CutTree(K int) {
 	if c.Dendrogram == nil { return }
 
+	// number of elements
+	m := len(c.Dendrogram) + 1
+
 	if K == 0 {
 		// by default, leave each element in its own cluster
-		K = len(c.X)
+		K = m
 	}
 	c.K = K
 
-	m := len(c.X)
 	uf := NewUnionFind(m)
 
 	// Starting with each element in its own cluster
@@ -63,9 +242,12 @@ func (c *HClusters) CutTree(K int) {
 func (c *HClusters) CutTreeHeight(height float64) {
 	if c.Dendrogram == nil { return }
 
-	m := len(c.X)
+	m := len(c.Dendrogram) + 1
 	uf := NewUnionFind(m)
 
+	// all elements are in one cluster, unless the tree is cut earlier
+	c.K = 1
+
 	// Starting with each element in its own cluster
 	// after each merge in the stepwise dendrogram, one less cluster remains
 	// therefore, (m - k) merges will occur
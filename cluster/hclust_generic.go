@@ -1,8 +1,8 @@
 package cluster
 
-//TODO  Add handling of different metrics
-
-import "fmt"
+import (
+	"fmt"
+)
 
 // node
 type node struct {
@@ -15,15 +15,27 @@ type node struct {
 }
 
 // Generic hierarchical clustering using Mullner's algorithm
+//
+// Distances are updated by the Lance-Williams formula of the linkage method.
+// As in R's hclust, the centroid and median updates are applied to the
+// dissimilarities as given (they are only geometrically meaningful for squared
+// Euclidean distances), while Ward's method squares the dissimilarities
+// internally and reports merge heights on the original scale (R's ward.D2).
 type HClustersGeneric struct {
 	HClusters
 	// one node for each data point
 	nodes []node
+	// working copy of the distances between active nodes (condensed)
+	dist *linkageDistances
 	// priority queue (key: minimum distances; value: node index)
 	priority Heap
 }
 
-func NewHClustersGeneric(X Matrix, metric MetricOp, method int, d *Distances) *HClustersGeneric {
+// NewHClustersGeneric returns an error if method is not a valid linkage method.
+func NewHClustersGeneric(X Matrix, metric MetricOp, method LinkageMethod, d DistanceProvider) (*HClustersGeneric, error) {
+	if !method.Valid() {
+		return nil, fmt.Errorf("cluster: invalid linkage method %v", method)
+	}
 	if d == nil {
 		d = NewDistances(X, metric)
 	}
@@ -34,29 +46,45 @@ func NewHClustersGeneric(X Matrix, metric MetricOp, method int, d *Distances) *H
 			Method: method,
 			D: d,
 		},
-	}
+	}, nil
 }
 
 func (c *HClustersGeneric) Cluster(k int) (classes *Classes) {
-	if c.D == nil { return }
+	classes, _ = c.Fit(k)
+	return
+}
 
-	c.initialize()
+// Fit is Cluster, but returns an error if the distances are invalid or k is not in [1, m].
+func (c *HClustersGeneric) Fit(k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
+		return
+	}
 
+	// the distances were checked
+	c.initialize()
 	c.cluster()
 
 	c.CutTree(k)
 
 	// copy classification information
 	classes = &Classes{
-		make([]int, len(c.X)), k, c.Cost }
+		make([]int, c.D.Len()), k, c.Cost, nil }
 	copy(classes.Index, c.Index)
 
 	return
 }
 
+// Hierarchize returns nil if D is nil or contains NaN (Fit reports the error).
+func (c *HClustersGeneric) Hierarchize() Linkages {
+	if checkDistances(c.D) != nil { return nil }
+	c.initialize()
+	c.cluster()
+	return c.Dendrogram
+}
+
 // assume initialization has been run
 func (c *HClustersGeneric) cluster() {
-	m := len(c.X)
+	m := c.D.Len()
 
 	// NB In updating nearest neighbour, only the node with the smaller index
 	//    has the correct information in a pair of nearest neighbour,
@@ -67,79 +95,34 @@ func (c *HClustersGeneric) cluster() {
 		// Choose a pair of nodes to merge
 
 		// get next nearest pair of nearest neighbours
-		a := c.priority.Pop()
-		nodeA := c.nodes[a]
-		b, distance := nodeA.nearest, nodeA.minDistance
+		a := c.priority.Peek()
 
 		// Re-calculate nearest neighbour, if necessary
-		for ; distance > c.D.rep[a][b]; {
-			// find new nearest neighbour for a
-			x := c.actives.Next(a)
-			min, minIdx := c.D.rep[a][x], x
-			for x = c.actives.Next(x); x < m; x = c.actives.Next(x) {
-				if c.D.rep[a][x] < min {
-					min = c.D.rep[a][x]
-				}
-			}
-			// update priority queue and node
-			c.priority.Push( KeyValue{Value:a, Key:min} )
-			nodeA.nearest = minIdx
-
-			// get next nearest pair of nearest neighbours again
-			a = c.priority.Pop()
-			nodeA = c.nodes[a]
-			b, distance = nodeA.nearest, nodeA.minDistance
+		// (minDistance is only a lower bound after previous merges)
+		for c.nodes[a].minDistance != c.dist.get(a, c.nodes[a].nearest) {
+			c.updateNearest(a)
+			a = c.priority.Peek()
 		}
-		// element a with min minDistance has been popped from the priority queue
+		// element a with min minDistance is removed from the priority queue
+		c.priority.Pop()
+		b, distance := c.nodes[a].nearest, c.nodes[a].minDistance
 
 		// Merge the pair of nearest nodes
 		// insert into dendrogram
-		c.Dendrogram[i] = Linkage{ First:a, Second:b, Distance:distance }
-		// use b as the index for the new node
-		nodeB := c.nodes[b]
-		nodeB.size += nodeA.size
+		c.Dendrogram[i] = Linkage{ First:a, Second:b, Distance:c.Method.height(distance) }
 		// mark node a as inactive
 		c.actives.Remove(a)
 
-
-		// FIXME: a was set a removed node, why?
-
 		// Update the distance matrix
-		fmt.Println("loop")
+		// use b as the index for the new node
+		sizeA, sizeB := float64(c.nodes[a].size), float64(c.nodes[b].size)
+		dAB := c.dist.get(a, b)
 		for x := c.actives.Begin(); x < m; x = c.actives.Next(x) {
-			if x != b {
-				// TODO use different update rule for different linkage methods
-				sizeA, sizeB := float64(nodeA.size), float64(nodeB.size)
-				var d float64
-				switch c.Method {
-					case single_linkage:
-						fmt.Println(a, b, x, c.actives)
-						if c.D.rep[a][x] <= c.D.rep[b][x] {
-							d = c.D.rep[a][x]
-						} else {
-							d = c.D.rep[b][x]
-						}
-					case complete_linkage:
-						if c.D.rep[a][x] >= c.D.rep[b][x] {
-							d = c.D.rep[a][x]
-						} else {
-							d = c.D.rep[b][x]
-						}
-					case average_linkage:
-						d = (sizeA * c.D.rep[a][x] + sizeB * c.D.rep[b][x]) / (sizeA + sizeB)
-					case mcquitty_linkage:
-						d = 0
-					case median_linkage:
-						d = 0
-					case centroid_linkage:
-						d = 0
-					case ward_linkage:
-						d = 0
-				}
-				c.D.rep[b][x] = d
-				c.D.rep[x][b] = d
-			}
+			if x == b { continue }
+			d := c.Method.update(c.dist.get(a, x), c.dist.get(b, x), dAB, sizeA, sizeB, float64(c.nodes[x].size))
+			c.dist.set(b, x, d)
 		}
+		c.nodes[b].size += c.nodes[a].size
 
 		// Update candidates for nearest neighour,
 		// to be corrected in the next iteration, if necessary
@@ -152,39 +135,53 @@ func (c *HClustersGeneric) cluster() {
 				c.nodes[x].nearest = b
 			}
 		}
-		
+
 		// Check if other nodes now have b as the nearest node
 		// Since the current nearest neighbour may be inaccurate...
 		for x := c.actives.Begin(); x < b; x = c.actives.Next(x) {
-			if c.D.rep[x][b] < c.nodes[x].minDistance {
+			if d := c.dist.get(x, b); d < c.nodes[x].minDistance {
 				// b is now the nearest neighbour for x
-				c.nodes[x].nearest = b
-				// preserve a lower bound for minDistance
-				d := c.D.rep[x][b]
-				c.nodes[x].minDistance = d
+				c.nodes[x].nearest, c.nodes[x].minDistance = b, d
 				// update priority queue: bottle neck in worst case time complexity
-				i := c.priority.Search(x)
-				c.priority.Update(i, KeyValue{d, x})
+				c.priority.Update(c.priority.Search(x), KeyValue{d, x})
 			}
 		}
 
 		// Update nearest neighbour for node b
-		min, minIdx := nodeB.minDistance, nodeB.nearest
-		for x := c.actives.Next(b); x < m; x = c.actives.Next(x) {
-			if c.D.rep[b][x] < min {
-				min, minIdx = c.D.rep[b][x], x
-			}
+		c.updateNearest(b)
+	}
+}
+
+// updateNearest searches the active nodes following node a for its nearest
+// neighbour and updates the priority queue accordingly.
+func (c *HClustersGeneric) updateNearest(a int) {
+	m := c.D.Len()
+	i := c.priority.Search(a)
+
+	x := c.actives.Next(a)
+	if x >= m {
+		// a is the last active node and has no neighbour to follow it
+		c.priority.Remove(i)
+		return
+	}
+
+	min, minIdx := c.dist.get(a, x), x
+	for x = c.actives.Next(x); x < m; x = c.actives.Next(x) {
+		if c.dist.get(a, x) < min {
+			min, minIdx = c.dist.get(a, x), x
 		}
-		c.nodes[b].minDistance, c.nodes[b].nearest = min, minIdx
-		// update priority queue
-		i := c.priority.Search(b)
-		c.priority.Update(i, KeyValue{min, minIdx})
+	}
+	c.nodes[a].nearest, c.nodes[a].minDistance = minIdx, min
 
+	if i < 0 {
+		c.priority.Push( KeyValue{Key:min, Value:a} )
+	} else {
+		c.priority.Update(i, KeyValue{Key:min, Value:a})
 	}
 }
 
 func (c *HClustersGeneric) initialize() {
-	m := len(c.X)
+	m := c.D.Len()
 
 	c.Dendrogram = make([]Linkage, m-1)
 
@@ -193,33 +190,30 @@ func (c *HClustersGeneric) initialize() {
 	// set of indices of active nodes
 	c.actives = NewActiveSet(m)
 
+	c.dist = c.Method.distances(c.D)
+
 	// Generate the list of nearest neighbours
 	// iterate from the first to the penultimate node
-	for i := c.actives.Begin(); i < m-1; i = c.actives.Next(i) {
-		// set nearest neighbour to the next node
-		j := i+1
-		min, minIdx := c.D.rep[i][j], j
+	for i := 0; i < m; i++ {
+		c.nodes[i] = node{nearest:-1, minDistance:maxValue, size:1}
 		// check later nodes
-		for j = i+2; j < m; j++ {
-			if c.D.rep[i][j] < min {
-				min = c.D.rep[i][j]
+		for j := i+1; j < m; j++ {
+			if c.dist.get(i, j) < c.nodes[i].minDistance {
+				c.nodes[i].nearest, c.nodes[i].minDistance = j, c.dist.get(i, j)
 			}
 		}
-		c.nodes[i] = node{nearest:minIdx, minDistance:min, size:1}
 		// not necessary to create reciprocal relationship
-		//nodes[minIdx] = node{i, min, 1}
 	}
-	
+
 	// Create priority queue
 
-	// create array with len and capacity m
-	minDistances := make([]KeyValue, m, m)
-	for i := 0; i < m; i++ {
-		minDistances[i] = KeyValue{ Key:c.nodes[i].minDistance, Value:i }
+	// the last node has no neighbours following it
+	minDistances := make([]KeyValue, 0, m)
+	for i := 0; i < m-1; i++ {
+		minDistances = append(minDistances, KeyValue{ Key:c.nodes[i].minDistance, Value:i })
 	}
 	// heapify array and store heap as class member
 	c.priority = Heap{ minDistances }
 	c.priority.Init()
 
 }
-
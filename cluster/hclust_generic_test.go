@@ -0,0 +1,147 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+var hClustersGenericTests = []struct{
+	x Matrix
+	metric MetricOp
+	method LinkageMethod
+	k int
+	index Partitions
+}{
+	{
+		Matrix{
+			{0, 0},
+			{1, 0},
+			{2, 0},
+			{3, 0},
+			{0, 3},
+			{1, 3},
+			{2, 3},
+			{3, 3},
+		},
+		Euclidean,
+		SingleLinkage,
+		2,
+		Partitions{1, 1, 1, 1, 2, 2, 2, 2},
+	},
+	{
+		Matrix{
+			{0, 0},
+			{1, 0},
+			{3, 0},
+			{4, 0},
+			{0, 4},
+			{1, 4},
+			{3, 4},
+			{4, 4},
+		},
+		Euclidean,
+		CompleteLinkage,
+		2,
+		Partitions{1, 1, 1, 1, 2, 2, 2, 2},
+	},
+	{
+		Matrix{
+			{0, 0},
+			{1, 0},
+			{3, 0},
+			{4, 0},
+			{0, 4},
+			{1, 4},
+			{3, 4},
+			{4, 4},
+		},
+		Euclidean,
+		AverageLinkage,
+		4,
+		Partitions{1, 1, 2, 2, 3, 3, 4, 4},
+	},
+	{
+		Matrix{
+			{0, 0},
+			{1, 0},
+			{3, 0},
+			{4, 0},
+			{0, 4},
+			{1, 4},
+			{3, 4},
+			{4, 4},
+		},
+		Euclidean,
+		WardLinkage,
+		2,
+		Partitions{1, 1, 1, 1, 2, 2, 2, 2},
+	},
+}
+
+func TestHClustersGeneric(t *testing.T) {
+	for i, test := range hClustersGenericTests {
+		c, err := NewHClustersGeneric(test.x, test.metric, test.method, nil)
+		if err != nil {
+			t.Fatalf("#%d NewHClustersGeneric(...) returned error: %v", i, err)
+		}
+		classes := c.Cluster(test.k)
+		if !classes.Index.Equal(test.index) {
+			t.Errorf("#%d HClustersGeneric.Cluster(%d) got %v, want %v", i, test.k, classes.Index, test.index)
+		}
+	}
+}
+
+// merge heights, as reported by R's hclust on dist(x)
+var hClustersGenericHeightsX = Matrix{
+	{0, 0}, {1, 0}, {3, 0}, {7, 0}, {7, 3}, {12, 1}, {2, 5},
+}
+
+var hClustersGenericHeightsTests = []struct{
+	method LinkageMethod
+	heights Vector
+}{
+	{SingleLinkage, Vector{1, 2, 3, 4, 5.0990195, 5.0990195}},
+	{CompleteLinkage, Vector{1, 3, 3, 5.3851648, 5.3851648, 12.0415946}},
+	{AverageLinkage, Vector{1, 2.5, 3, 5.1944013, 5.2420922, 7.6410733}},
+	{McQuittyLinkage, Vector{1, 2.5, 3, 5.1705558, 5.2420922, 8.2408436}},
+	{MedianLinkage, Vector{1, 2.25, 3, 4.2279971, 3.9235868, 7.4017744}},
+	{CentroidLinkage, Vector{1, 2.25, 3, 4.4920922, 4.5277346, 4.7938804}},
+	{WardLinkage, Vector{1, 2.8867513, 3, 5.8022984, 6.1779177, 13.2709852}},
+}
+
+func TestHClustersGenericHeights(t *testing.T) {
+	x := hClustersGenericHeightsX
+	for i, test := range hClustersGenericHeightsTests {
+		d := NewDistances(x, Euclidean)
+		original := d.Condensed()
+
+		c, err := NewHClustersGeneric(x, Euclidean, test.method, d)
+		if err != nil {
+			t.Fatalf("#%d NewHClustersGeneric(...) returned error: %v", i, err)
+		}
+		linkages := c.Hierarchize()
+
+		heights := make(Vector, len(linkages))
+		for j, linkage := range linkages {
+			heights[j] = linkage.Distance
+		}
+		if !mlgo.Vector(heights).Equal(mlgo.Vector(test.heights)) {
+			t.Errorf("#%d HClustersGeneric.Hierarchize() got heights %v, want %v", i, heights, test.heights)
+		}
+		if !mlgo.Vector(d.Condensed()).Equal(mlgo.Vector(original)) {
+			t.Errorf("#%d HClustersGeneric.Hierarchize() modified the distances", i)
+		}
+	}
+}
+
+func TestHClustersGenericNaN(t *testing.T) {
+	d, _ := NewDistancesFromCondensed(Vector{1, math.NaN(), 2, 4, 3, 5})
+	for _, method := range []LinkageMethod{SingleLinkage, CentroidLinkage} {
+		c, _ := NewHClustersGeneric(nil, Euclidean, method, d)
+		// would not terminate if the NaN distance were used
+		if linkages := c.Hierarchize(); linkages != nil {
+			t.Errorf("HClustersGeneric.Hierarchize() with method %v and NaN distance got %v, want nil", method, linkages)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Hierarchical clustering using the nearest-neighbour chain algorithm
+//
+// The algorithm requires O(m^2) time and is applicable to linkage methods that
+// satisfy the reducibility property: single, complete, average, McQuitty and
+// Ward linkage. Merges are found in a different order than in the generic
+// algorithm, but the resulting dendrogram is sorted by merge distance, s.t.
+// it is the same as the dendrogram produced by HClustersGeneric.
+type HClustersNNChain struct {
+	HClusters
+	// working copy of the distances between active nodes (condensed)
+	dist *linkageDistances
+	// size of cluster headed by each node
+	sizes []int
+	// chain of nearest neighbours
+	chain []int
+}
+
+// NewHClustersNNChain returns an error if method is not a reducible linkage method.
+func NewHClustersNNChain(X Matrix, metric MetricOp, method LinkageMethod, d DistanceProvider) (*HClustersNNChain, error) {
+	if !method.reducible() {
+		return nil, fmt.Errorf("cluster: linkage method %v is not supported by the nearest-neighbour chain algorithm", method)
+	}
+	if d == nil {
+		d = NewDistances(X, metric)
+	}
+	return &HClustersNNChain{
+		HClusters: HClusters{
+			X: X,
+			Metric: metric,
+			Method: method,
+			D: d,
+		},
+	}, nil
+}
+
+func (c *HClustersNNChain) Cluster(k int) (classes *Classes) {
+	classes, _ = c.Fit(k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the distances are invalid or k is not in [1, m].
+func (c *HClustersNNChain) Fit(k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
+		return
+	}
+
+	// the distances were checked
+	c.initialize()
+	c.cluster()
+
+	c.CutTree(k)
+
+	// copy classification information
+	classes = &Classes{
+		make([]int, c.D.Len()), k, c.Cost, nil }
+	copy(classes.Index, c.Index)
+
+	return
+}
+
+// Hierarchize returns nil if D is nil or contains NaN (Fit reports the error).
+func (c *HClustersNNChain) Hierarchize() Linkages {
+	if checkDistances(c.D) != nil { return nil }
+	c.initialize()
+	c.cluster()
+	return c.Dendrogram
+}
+
+func (c *HClustersNNChain) initialize() {
+	m := c.D.Len()
+
+	c.Dendrogram = make([]Linkage, m-1)
+
+	c.actives = NewActiveSet(m)
+
+	c.dist = c.Method.distances(c.D)
+
+	c.sizes = make([]int, m)
+	for i := range c.sizes {
+		c.sizes[i] = 1
+	}
+
+	c.chain = make([]int, 0, m)
+}
+
+// assume initialization has been run
+func (c *HClustersNNChain) cluster() {
+	m := c.D.Len()
+
+	// (m - 1) merges will occur in main loop
+	for i := 0; i < m-1; i++ {
+
+		// start a new chain from any active node
+		if len(c.chain) == 0 {
+			c.chain = append(c.chain, c.actives.Begin())
+		}
+
+		// Grow the chain until a pair of reciprocal nearest neighbours is found
+		var a, b int
+		for {
+			n := len(c.chain)
+			a = c.chain[n-1]
+			// prefer the previous element of the chain in case of ties,
+			// s.t. the chain terminates
+			b = -1
+			min := maxValue
+			if n > 1 {
+				b = c.chain[n-2]
+				min = c.dist.get(a, b)
+			}
+			for x := c.actives.Begin(); x < m; x = c.actives.Next(x) {
+				if x != a && c.dist.get(a, x) < min {
+					b, min = x, c.dist.get(a, x)
+				}
+			}
+			if n > 1 && b == c.chain[n-2] {
+				// a and b are reciprocal nearest neighbours
+				break
+			}
+			c.chain = append(c.chain, b)
+		}
+		// remove a and b from the chain
+		c.chain = c.chain[:len(c.chain)-2]
+
+		// Merge the pair of reciprocal nearest neighbours
+		// use the greater index for the new node, as in the generic algorithm
+		if a > b {
+			a, b = b, a
+		}
+		c.Dendrogram[i] = Linkage{ First:a, Second:b, Distance:c.Method.height(c.dist.get(a, b)) }
+		c.actives.Remove(a)
+
+		// Update the distance matrix
+		sizeA, sizeB := float64(c.sizes[a]), float64(c.sizes[b])
+		dAB := c.dist.get(a, b)
+		for x := c.actives.Begin(); x < m; x = c.actives.Next(x) {
+			if x == b { continue }
+			d := c.Method.update(c.dist.get(a, x), c.dist.get(b, x), dAB, sizeA, sizeB, float64(c.sizes[x]))
+			c.dist.set(b, x, d)
+		}
+		c.sizes[b] += c.sizes[a]
+	}
+
+	// Merges are found out of order: sort by merge distance
+	// N.B. Reducibility guarantees that a cluster is never merged at a distance
+	//      smaller than the distance at which it was formed
+	sort.Stable(c.Dendrogram)
+}
@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"math/rand"
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+func TestHClustersNNChainHeights(t *testing.T) {
+	x := hClustersGenericHeightsX
+	for i, test := range hClustersGenericHeightsTests {
+		if !test.method.reducible() || test.method == SingleLinkage {
+			continue
+		}
+		c, err := NewHClustersNNChain(x, Euclidean, test.method, nil)
+		if err != nil {
+			t.Fatalf("#%d NewHClustersNNChain(...) returned error: %v", i, err)
+		}
+		linkages := c.Hierarchize()
+
+		heights := make(Vector, len(linkages))
+		for j, linkage := range linkages {
+			heights[j] = linkage.Distance
+		}
+		if !mlgo.Vector(heights).Equal(mlgo.Vector(test.heights)) {
+			t.Errorf("#%d HClustersNNChain.Hierarchize() got heights %v, want %v", i, heights, test.heights)
+		}
+	}
+}
+
+func TestHClustersNNChainGeneric(t *testing.T) {
+	const m, n = 60, 3
+	r := rand.New(rand.NewSource(1))
+	x := make(Matrix, m)
+	for i := range x {
+		x[i] = make(Vector, n)
+		for j := range x[i] {
+			x[i][j] = r.NormFloat64()
+		}
+	}
+	d := NewDistances(x, Euclidean)
+
+	for _, method := range []LinkageMethod{CompleteLinkage, AverageLinkage, McQuittyLinkage, WardLinkage} {
+		g, _ := NewHClustersGeneric(x, Euclidean, method, d)
+		c, err := NewHClustersNNChain(x, Euclidean, method, d)
+		if err != nil {
+			t.Fatalf("NewHClustersNNChain(..., %v, ...) returned error: %v", method, err)
+		}
+		want, got := g.Hierarchize(), c.Hierarchize()
+		for i := range want {
+			if want[i].First != got[i].First || want[i].Second != got[i].Second ||
+				!mlgo.EssentiallyEqual(want[i].Distance, got[i].Distance, 1e-9) {
+				t.Errorf("%v: HClustersNNChain.Hierarchize()[%d] got %v, want %v", method, i, got[i], want[i])
+			}
+		}
+	}
+
+	if _, err := NewHClustersNNChain(x, Euclidean, CentroidLinkage, d); err == nil {
+		t.Errorf("NewHClustersNNChain(..., %v, ...) got no error", CentroidLinkage)
+	}
+}
@@ -1,14 +1,20 @@
 package cluster
 
+import (
+	"sort"
+)
+
 // Single linkage hierarchical clustering using Minimum Spanning Tree (MST) algorithm
 type HClustersSingle struct {
 	HClusters
 
 	// minimum distances from each element to the growing MST
 	minDistances []float64
+	// element in the growing MST that is nearest to each element
+	nearest []int
 }
 
-func NewHClustersSingle(X Matrix, metric MetricOp, d *Distances) *HClustersSingle {
+func NewHClustersSingle(X Matrix, metric MetricOp, d DistanceProvider) *HClustersSingle {
 	if d == nil {
 		d = NewDistances(X, metric)
 	}
@@ -16,50 +22,61 @@ func NewHClustersSingle(X Matrix, metric MetricOp, d *Distances) *HClustersSingl
 		HClusters: HClusters{
 			X: X,
 			Metric: metric,
-			Method: single_linkage,
+			Method: SingleLinkage,
 			D: d,
 		},
 	}
 }
 
 func (c *HClustersSingle) Cluster(k int) (classes *Classes) {
-	if c.D == nil { return }
+	classes, _ = c.Fit(k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the distances are invalid or k is not in [1, m].
+func (c *HClustersSingle) Fit(k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
+		return
+	}
 
-	c.Hierarchize()
+	c.initialize()
+	c.cluster()
 
 	c.K = k
 	c.CutTree(k)
 
 	// copy classification information
 	classes = &Classes{
-		make([]int, len(c.X)), k, c.Cost }
+		make([]int, c.D.Len()), k, c.Cost, nil }
 	copy(classes.Index, c.Index)
 
 	return
 }
 
+// Hierarchize returns nil if D is nil or contains NaN (Fit reports the error).
 func (c *HClustersSingle) Hierarchize() Linkages {
-	if c.D == nil { return nil }
+	if checkDistances(c.D) != nil { return nil }
 	c.initialize()
 	c.cluster()
 	return c.Dendrogram
 }
 
 func (c *HClustersSingle) initialize() {
-	m := len(c.X)
+	m := c.D.Len()
 
 	c.Dendrogram = make([]Linkage, m-1)
 
 	c.actives =  NewActiveSet(m)
 
 	c.minDistances = make([]float64, m)
+	c.nearest = make([]int, m)
 	for i := range c.minDistances {
 		c.minDistances[i] = maxValue
 	}
 }
 
 func (c *HClustersSingle) cluster() {
-	m := len(c.X)
+	m := c.D.Len()
 	
 	// Simplifed MST method based on Prim's algorithm
 	
@@ -76,9 +93,9 @@ func (c *HClustersSingle) cluster() {
 		// while keeping track of minimum
 		min, minIdx := maxValue, 0
 		for s := c.actives.Begin(); s < m; s = c.actives.Next(s) {
-			d := c.D.rep[s][current]
+			d := c.D.Get(s, current)
 			if d < c.minDistances[s] {
-				c.minDistances[s] = d
+				c.minDistances[s], c.nearest[s] = d, current
 			}
 			// keep track of minimum
 			if c.minDistances[s] < min {
@@ -87,23 +104,15 @@ func (c *HClustersSingle) cluster() {
 		}
 
 		// Add the element with the minimum minDistance to the growing MST
-		c.Dendrogram[i] = Linkage{ First:current, Second:minIdx, Distance:min }
+		c.Dendrogram[i] = Linkage{ First:c.nearest[minIdx], Second:minIdx, Distance:min }
 		
 		// Procceed onto the newly added element
 		current = minIdx
 	}
 
-	// Sort by indices, so that the most similar uptime columns are right next
-	// to each other.
-	for i := 0; i < len(c.Dendrogram)-2; i++ {
-		for j := i+2; j < len(c.Dendrogram); j++ {
-			if c.Dendrogram[i].Second == c.Dendrogram[j].First {
-				tmp := c.Dendrogram[i+1]
-				c.Dendrogram[i+1] = c.Dendrogram[j]
-				c.Dendrogram[j] = tmp
-			}
-		}
-	}
+	// The MST edges are the single linkage merges,
+	// which occur in the order of increasing distance
+	sort.Stable(c.Dendrogram)
 }
 
 
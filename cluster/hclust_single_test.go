@@ -1,6 +1,8 @@
 package cluster
 
 import (
+	"math"
+	"reflect"
 	"testing"
 )
 
@@ -67,3 +69,14 @@ func TestHClustersSingle(t *testing.T) {
 	}
 }
 
+
+func TestHClustersSingleNaN(t *testing.T) {
+	d, _ := NewDistancesFromCondensed(Vector{1, math.NaN(), 2})
+	c := NewHClustersSingle(nil, nil, d)
+	if linkages := c.Hierarchize(); linkages != nil {
+		t.Errorf("HClustersSingle.Hierarchize() with NaN distance got %v, want nil", linkages)
+	}
+	if _, err := c.Fit(2); !reflect.DeepEqual(err, &NaNError{0, 2}) {
+		t.Errorf("HClustersSingle.Fit(2) with NaN distance got error %v, want %v", err, &NaNError{0, 2})
+	}
+}
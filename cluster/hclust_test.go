@@ -0,0 +1,40 @@
+package cluster
+
+import (
+	"testing"
+)
+
+func TestParseLinkageMethod(t *testing.T) {
+	for method := SingleLinkage; method <= WardLinkage; method++ {
+		parsed, err := ParseLinkageMethod(method.String())
+		if err != nil || parsed != method {
+			t.Errorf("ParseLinkageMethod(%q) got %v, %v, want %v", method.String(), parsed, err, method)
+		}
+	}
+	if parsed, err := ParseLinkageMethod("Ward"); err != nil || parsed != WardLinkage {
+		t.Errorf("ParseLinkageMethod(%q) got %v, %v, want %v", "Ward", parsed, err, WardLinkage)
+	}
+	if _, err := ParseLinkageMethod("nearest"); err == nil {
+		t.Errorf("ParseLinkageMethod(%q) got no error", "nearest")
+	}
+}
+
+func TestNewHierarchical(t *testing.T) {
+	test := hClustersSingleTests[0]
+	for method := SingleLinkage; method <= WardLinkage; method++ {
+		c, err := NewHierarchical(test.x, test.metric, method, nil)
+		if err != nil {
+			t.Fatalf("NewHierarchical(..., %v, nil) returned error: %v", method, err)
+		}
+		if _, ok := c.(*HClustersSingle); ok != (method == SingleLinkage) {
+			t.Errorf("NewHierarchical(..., %v, nil) got %T", method, c)
+		}
+		classes := c.Cluster(test.k)
+		if !classes.Index.Equal(test.index) {
+			t.Errorf("NewHierarchical(..., %v, nil).Cluster(%d) got %v, want %v", method, test.k, classes.Index, test.index)
+		}
+	}
+	if _, err := NewHierarchical(test.x, test.metric, LinkageMethod(-1), nil); err == nil {
+		t.Errorf("NewHierarchical(..., %v, nil) got no error", LinkageMethod(-1))
+	}
+}
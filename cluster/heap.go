@@ -31,6 +31,12 @@ func (h *Heap) Push(x KeyValue) {
 	siftup(h.elements, n)
 }
 
+// Peek returns the minimum element without removing it
+func (h *Heap) Peek() int {
+	if len(h.elements) == 0 { return -1 }
+	return h.elements[0].Value
+}
+
 // Pop removes and returns the minimum element
 // complexity is O(log(n))
 func (h *Heap) Pop() (y int) {
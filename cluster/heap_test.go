@@ -65,7 +65,7 @@ func TestHeap(t *testing.T) {
 	sort.Sort(x)
 
 	if s := h.Search( x.Min().Value ); s != 0 {
-		t.Errorf("Element with min key found at position %d in heap, expected", s, 0)
+		t.Errorf("Element with min key found at position %d in heap, expected %d", s, 0)
 	}
 
 	for i := 0; i < x.Len(); i++ {
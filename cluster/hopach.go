@@ -1,5 +1,11 @@
 package cluster
 
+import (
+	"github.com/NullHypothesis/mlgo"
+	"math"
+	"sort"
+)
+
 type Hopacher interface {
 	Clusterer
 	Subset(index []int) Hopacher
@@ -9,8 +15,20 @@ type Hopacher interface {
 	Sort()
 }
 
+// HopachSplitter is implemented by partitioning algorithms (e.g. KMedoids)
+// that HOPACH can use to split clusters.
+type HopachSplitter interface {
+	Splitter
+	// Distances between data points
+	Distances() DistanceProvider
+}
+
+// Hierarchical Ordered Partitioning And Collapsing Hybrid
+// (van der Laan and Pollard, 2003)
 type Hopach struct {
-	Base Hopacher
+	HClusters
+
+	Base HopachSplitter
 
 	maxLevel, maxK, maxL int
 	// implemented parameters
@@ -20,10 +38,37 @@ type Hopach struct {
 	// mss = med
 	// initord = co
 	// ord = neighbour
+
+	// Partitioning at each level of the tree; level 0 is the root
+	// Clusters are labelled in order; Cost is the median split silhouette
+	Levels []*Classes
+	// Level with the minimum median split silhouette
+	Best int
+	// Final ordering of the data points
+	Order []int
+
+	// clusters at each level of the tree, in order
+	levels [][]hopachCluster
+}
+
+type hopachCluster struct {
+	// indices of the data points in the cluster
+	members []int
+	// index of the medoid data point
+	medoid int
+	// position of the parent cluster in the previous level
+	parent int
+	// split silhouette
+	splitSil float64
+	// whether the cluster can be split further
+	splittable bool
 }
 
-func NewHopach(base Hopacher) *Hopach {
+func NewHopach(base HopachSplitter) *Hopach {
 	return &Hopach{
+		HClusters: HClusters{
+			D: base.Distances(),
+		},
 		Base: base,
 		maxLevel: 15,
 		maxK: 9,
@@ -31,8 +76,391 @@ func NewHopach(base Hopacher) *Hopach {
 	}
 }
 
+// Cluster returns the clusters at the level with the minimum
+// median split silhouette if k <= 0 (clusters = best);
+// otherwise, the tree is cut to generate k clusters.
+func (h *Hopach) Cluster(k int) (classes *Classes) {
+	classes, _ = h.Fit(k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the distances are invalid or k > m.
+func (h *Hopach) Fit(k int) (classes *Classes, err error) {
+	if err = checkDistances(h.D); err != nil {
+		return
+	}
+	if k > 0 {
+		if err = checkK(k, h.Len(), h.Len()); err != nil {
+			return
+		}
+	}
+	if h.Dendrogram == nil {
+		h.Hierarchize()
+	}
+
+	if k <= 0 {
+		best := h.Levels[h.Best]
+		classes = &Classes{
+			make([]int, h.Len()), best.K, best.Cost, nil }
+		copy(classes.Index, best.Index)
+		return
+	}
+
+	h.CutTree(k)
+
+	// copy classification information
+	classes = &Classes{
+		make([]int, h.Len()), k, h.Cost, nil }
+	copy(classes.Index, h.Index)
+
+	return
+}
+
 func (h *Hopach) Hierarchize() Linkages {
+	m := h.Len()
 
-	return nil
+	root := h.newCluster(mlgo.Range(0, m), -1)
+	h.levels = [][]hopachCluster{ {root} }
+	mss := Vector{ h.medianSplitSil(h.levels[0]) }
+
+	// Main levels: split clusters by median split silhouette and collapse
+	for l := 1; l < h.maxLevel; l++ {
+		level, split := h.splitLevel(h.levels[l-1], false)
+		if !split {
+			break
+		}
+		level = h.collapse(level)
+		h.levels = append(h.levels, level)
+		mss = append(mss, h.medianSplitSil(level))
+	}
+
+	// clusters = best: choose the main level with the minimum MSS
+	h.Best = 0
+	for l, x := range mss {
+		if x < mss[h.Best] {
+			h.Best = l
+		}
+	}
+
+	// Final level ordering: continue splitting until all clusters are singletons
+	for level := h.levels[len(h.levels)-1]; len(level) < m; {
+		level, _ = h.splitLevel(level, true)
+		h.levels = append(h.levels, level)
+	}
+
+	h.Levels = make([]*Classes, len(h.levels))
+	for l, level := range h.levels {
+		classes := &Classes{ make([]int, m), len(level), 0, nil }
+		if l < len(mss) {
+			classes.Cost = mss[l]
+		}
+		for kk, cl := range level {
+			for _, i := range cl.members {
+				classes.Index[i] = kk
+			}
+		}
+		h.Levels[l] = classes
+	}
+
+	leaves := h.levels[len(h.levels)-1]
+	h.Order = make([]int, len(leaves))
+	for i, cl := range leaves {
+		h.Order[i] = cl.members[0]
+	}
+
+	h.linkage()
+
+	return h.Dendrogram
+}
+
+// linkage builds the dendrogram from the levels of the tree.
+// Children clusters are merged into their parents, starting from the
+// bottom level; the distance of a merge is the height of the parent level.
+func (h *Hopach) linkage() {
+	T := len(h.levels) - 1
+	h.Dendrogram = make(Linkages, 0, h.Len()-1)
+	for t := T; t > 0; t-- {
+		height := float64(T - t + 1)
+		level := h.levels[t]
+		for i := 0; i < len(level); {
+			// children of the same parent are adjacent
+			first := level[i].members[0]
+			j := i + 1
+			for ; j < len(level) && level[j].parent == level[i].parent; j++ {
+				h.Dendrogram = append(h.Dendrogram,
+					Linkage{ First:first, Second:level[j].members[0], Distance:height })
+			}
+			i = j
+		}
+	}
 }
 
+// splitLevel splits each cluster of the level into ordered children clusters.
+// If force is false, the number of children is chosen by minimizing the median
+// split silhouette, and clusters may remain unsplit.
+// If force is true, each cluster with more than one element is split
+// (final level ordering); clusters are split into singletons at the maximum level.
+func (h *Hopach) splitLevel(level []hopachCluster, force bool) (next []hopachCluster, split bool) {
+	// whether the next level is the last level permitted
+	last := len(h.levels)+1 >= h.maxLevel
+	for j, cl := range level {
+		var partitions [][]int
+		n := len(cl.members)
+		switch {
+			case n == 1:
+			case force && (last || n < 3):
+				partitions = singletons(cl.members)
+			case force:
+				if s := SegregateByMeanSil(h.Base.Subset(cl.members), h.maxK); s.K > 0 {
+					partitions = h.members(cl.members, s.Cl)
+				}
+			default:
+				s := SplitByMedianSplitSil(h.Base.Subset(cl.members), h.maxK, h.maxL)
+				if s.K > 1 {
+					partitions = h.members(cl.members, s.Cl)
+				}
+		}
+		if force && n > 1 && len(partitions) < 2 {
+			partitions = singletons(cl.members)
+		}
+
+		if len(partitions) < 2 {
+			// cluster is not split: carry it over to the next level
+			child := cl
+			child.parent = j
+			next = append(next, child)
+			continue
+		}
+		split = true
+
+		children := make([]hopachCluster, len(partitions))
+		for kk, members := range partitions {
+			if force {
+				children[kk] = hopachCluster{ members:members, medoid:h.medoid(members), parent:j }
+			} else {
+				children[kk] = h.newCluster(members, j)
+			}
+		}
+		next = append(next, h.order(level, j, children)...)
+	}
+	return
+}
+
+// collapse sequentially merges neighbouring clusters with the same parent,
+// if the median split silhouette of the level is reduced (coll = seq).
+// Collapsing is restricted to siblings, s.t. the levels of the tree remain nested.
+func (h *Hopach) collapse(level []hopachCluster) []hopachCluster {
+	mss := h.medianSplitSil(level)
+	for i := 0; i < len(level)-1; {
+		if level[i].parent != level[i+1].parent {
+			i++
+			continue
+		}
+		// the merged cluster may be collapsed with its next neighbour
+		if collapsed := h.merge(level, i, i+1); h.medianSplitSil(collapsed) < mss {
+			level, mss = collapsed, h.medianSplitSil(collapsed)
+		} else {
+			i++
+		}
+	}
+	return level
+}
+
+// merge returns a copy of the level with cluster j merged into cluster i, i < j.
+func (h *Hopach) merge(level []hopachCluster, i, j int) []hopachCluster {
+	a, b := level[i], level[j]
+	members := make([]int, 0, len(a.members)+len(b.members))
+	members = append(append(members, a.members...), b.members...)
+	merged := h.newCluster(members, a.parent)
+	// newmed = nn: new medoid is nearest to the weighted mean of the old medoids
+	merged.medoid = h.nearestMedoid(members, a, b)
+
+	collapsed := make([]hopachCluster, 0, len(level)-1)
+	collapsed = append(collapsed, level[:i]...)
+	collapsed = append(collapsed, merged)
+	collapsed = append(collapsed, level[i+1:j]...)
+	collapsed = append(collapsed, level[j+1:]...)
+	return collapsed
+}
+
+// order orders the children of cluster j of level (ord = neighbour):
+// children nearest to the medoid of the left neighbour of cluster j come first;
+// otherwise, children farthest from the right neighbour come first.
+// If cluster j has no neighbours, the children medoids are ordered s.t. the
+// correlation between distances and positions is maximized (initord = co).
+func (h *Hopach) order(level []hopachCluster, j int, children []hopachCluster) []hopachCluster {
+	p := make(pairs, len(children))
+	switch {
+		case j > 0:
+			left := level[j-1].medoid
+			for kk, cl := range children {
+				p[kk] = pair{ key:h.D.Get(cl.medoid, left), value:kk }
+			}
+		case j < len(level)-1:
+			right := level[j+1].medoid
+			for kk, cl := range children {
+				p[kk] = pair{ key:-h.D.Get(cl.medoid, right), value:kk }
+			}
+		default:
+			medoids := make([]int, len(children))
+			for kk, cl := range children {
+				medoids[kk] = cl.medoid
+			}
+			for pos, kk := range correlationOrder(h.D, medoids, h.maxK) {
+				p[pos] = pair{ key:float64(pos), value:kk }
+			}
+	}
+	sort.Stable(p)
+
+	ordered := make([]hopachCluster, len(children))
+	for pos, x := range p {
+		ordered[pos] = children[x.value]
+	}
+	return ordered
+}
+
+// correlationOrder returns the ordering of the elements that maximizes the
+// correlation between their distances and the distances of their positions.
+// All orderings are considered for up to maxK elements (the maximum number of
+// children of a cluster); otherwise, the elements are ordered greedily by nearest neighbours.
+func correlationOrder(D DistanceProvider, elements []int, maxK int) (order []int) {
+	k := len(elements)
+	order = mlgo.Range(0, k)
+	if k < 3 {
+		return
+	}
+
+	if k > maxK {
+		// start from the element that is farthest from all others
+		start, max := 0, -1.0
+		for a := 0; a < k; a++ {
+			sum := 0.0
+			for b := 0; b < k; b++ {
+				sum += D.Get(elements[a], elements[b])
+			}
+			if sum > max {
+				start, max = a, sum
+			}
+		}
+		order[0], order[start] = order[start], order[0]
+		for i := 1; i < k; i++ {
+			nearest := i
+			for j := i+1; j < k; j++ {
+				if D.Get(elements[order[i-1]], elements[order[j]]) < D.Get(elements[order[i-1]], elements[order[nearest]]) {
+					nearest = j
+				}
+			}
+			order[i], order[nearest] = order[nearest], order[i]
+		}
+		return
+	}
+
+	// correlation between distances and position distances
+	correlation := func(perm []int) float64 {
+		var x, y, xx, yy, xy, n float64
+		for a := 0; a < k; a++ {
+			for b := a+1; b < k; b++ {
+				u := D.Get(elements[perm[a]], elements[perm[b]])
+				v := float64(b - a)
+				x, y, xx, yy, xy = x+u, y+v, xx+u*u, yy+v*v, xy+u*v
+				n++
+			}
+		}
+		return (n*xy - x*y) / (math.Sqrt(n*xx - x*x) * math.Sqrt(n*yy - y*y))
+	}
+
+	// enumerate permutations using Heap's algorithm
+	perm := mlgo.Range(0, k)
+	max := correlation(perm)
+	counters := make([]int, k)
+	for i := 1; i < k; {
+		if counters[i] < i {
+			if i % 2 == 0 {
+				perm[0], perm[i] = perm[i], perm[0]
+			} else {
+				perm[counters[i]], perm[i] = perm[i], perm[counters[i]]
+			}
+			if r := correlation(perm); r > max {
+				max = r
+				copy(order, perm)
+			}
+			counters[i]++
+			i = 1
+		} else {
+			counters[i] = 0
+			i++
+		}
+	}
+	return
+}
+
+// newCluster returns a cluster with the medoid and split silhouette calculated.
+func (h *Hopach) newCluster(members []int, parent int) hopachCluster {
+	cl := hopachCluster{ members:members, medoid:h.medoid(members), parent:parent }
+	cl.splitSil, cl.splittable = SplitSil(h.Base.Subset(members), h.maxL)
+	return cl
+}
+
+// medianSplitSil returns the median split silhouette of the level.
+// Clusters that cannot be split do not contribute.
+func (h *Hopach) medianSplitSil(level []hopachCluster) float64 {
+	sils := make(Vector, 0, len(level))
+	for _, cl := range level {
+		if cl.splittable {
+			sils = append(sils, cl.splitSil)
+		}
+	}
+	if len(sils) == 0 {
+		return 0
+	}
+	return median(sils)
+}
+
+// medoid returns the member with the minimum total distance to other members.
+func (h *Hopach) medoid(members []int) (medoid int) {
+	min := maxValue
+	for _, i := range members {
+		sum := 0.0
+		for _, j := range members {
+			sum += h.D.Get(i, j)
+		}
+		if sum < min {
+			medoid, min = i, sum
+		}
+	}
+	return
+}
+
+// nearestMedoid returns the member that is nearest to the mean of the
+// medoids of clusters a and b, weighted by cluster sizes.
+func (h *Hopach) nearestMedoid(members []int, a, b hopachCluster) (medoid int) {
+	na, nb := float64(len(a.members)), float64(len(b.members))
+	min := maxValue
+	for _, i := range members {
+		d := (na * h.D.Get(i, a.medoid) + nb * h.D.Get(i, b.medoid)) / (na + nb)
+		if d < min {
+			medoid, min = i, d
+		}
+	}
+	return
+}
+
+// members returns the partitions of the subset of data points specified by
+// index, using indices of all data points; empty partitions are removed.
+func (h *Hopach) members(index []int, classes *Classes) (partitions [][]int) {
+	for _, p := range classes.Partitions() {
+		if len(p) > 0 {
+			partitions = append(partitions, Permute(index, p))
+		}
+	}
+	return
+}
+
+// singletons returns each element in its own partition.
+func singletons(index []int) (partitions [][]int) {
+	partitions = make([][]int, len(index))
+	for i, x := range index {
+		partitions[i] = []int{x}
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+var hopachTests = []struct {
+	x Matrix
+	metric MetricOp
+	index Partitions
+}{
+	{
+		Matrix{
+			{1, 1}, {4, 4}, {5, 5}, {2, 2},
+			{53, 53}, {57, 57}, {54, 54}, {56, 56},
+			{91, 91}, {92, 92}, {94, 94}, {95, 95},
+		},
+		Manhattan,
+		Partitions{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2},
+	},
+}
+
+func TestHopach(t *testing.T) {
+	for i, test := range hopachTests {
+		h := NewHopach(NewKMedoids(test.x, test.metric, nil))
+		linkages := h.Hierarchize()
+		m := len(test.x)
+		if len(linkages) != m-1 {
+			t.Errorf("#%d Hopach.Hierarchize() got %d linkages, want %d", i, len(linkages), m-1)
+		}
+
+		// final ordering is a permutation of the data points
+		seen := make([]bool, m)
+		for _, x := range h.Order {
+			seen[x] = true
+		}
+		for x := range seen {
+			if !seen[x] {
+				t.Errorf("#%d Hopach.Order %v is missing %d", i, h.Order, x)
+			}
+		}
+
+		classes := h.Cluster(0)
+		if !classes.Index.Equal(test.index) {
+			t.Errorf("#%d Hopach.Cluster(0) got %v, want %v", i, classes.Index, test.index)
+		}
+
+		classes = h.Cluster(3)
+		if !classes.Index.Equal(test.index) {
+			t.Errorf("#%d Hopach.Cluster(3) got %v, want %v", i, classes.Index, test.index)
+		}
+	}
+}
+
+func TestHopachLevels(t *testing.T) {
+	test := hopachTests[0]
+	base := NewKMedoids(test.x, test.metric, nil)
+	base.Algorithm = PAM
+	h := NewHopach(base)
+	h.Hierarchize()
+
+	// the root is split into the three groups, which is the best level
+	if h.Best != 1 || !h.Levels[1].Index.Equal(test.index) {
+		t.Errorf("Hopach.Hierarchize() got best level %d, level 1 %v, want 1, %v", h.Best, h.Levels[1].Index, test.index)
+	}
+	// the data points lie on a line: the final order follows it
+	order := []int{0, 3, 1, 2, 4, 6, 7, 5, 8, 9, 10, 11}
+	if !reflect.DeepEqual(h.Order, order) {
+		t.Errorf("Hopach.Hierarchize() got order %v, want %v", h.Order, order)
+	}
+}
+
+func TestHopachCollapse(t *testing.T) {
+	x := Matrix{
+		{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}, {6, 6},
+		{50, 50}, {51, 51}, {52, 52}, {53, 53},
+		{90, 90}, {91, 91}, {92, 92}, {93, 93},
+	}
+	base := NewKMedoids(x, Manhattan, nil)
+	base.Algorithm = PAM
+	h := NewHopach(base)
+
+	// the second group is split into two halves, which cannot be split further:
+	// merging them reduces the median split silhouette
+	level := func(members [][]int, parents ...int) []hopachCluster {
+		level := make([]hopachCluster, len(members))
+		for kk := range members {
+			level[kk] = h.newCluster(members[kk], parents[kk])
+		}
+		return level
+	}
+	halves := [][]int{{0, 1, 2, 3, 4, 5}, {6, 7}, {8, 9}, {10, 11, 12, 13}}
+	quarters := [][]int{{0, 1, 2, 3, 4, 5}, {6, 7}, {8, 9}, {10, 11}, {12, 13}}
+	pieces := [][]int{{0, 1, 2, 3, 4, 5}, {6, 7}, {8}, {9}, {10, 11, 12, 13}}
+	var tests = []struct {
+		level []hopachCluster
+		members [][]int
+	}{
+		{level(halves, 0, 1, 1, 2), [][]int{{0, 1, 2, 3, 4, 5}, {6, 7, 8, 9}, {10, 11, 12, 13}}},
+		// the halves are not siblings: they must not be merged
+		{level(halves, 0, 1, 2, 3), [][]int{{0, 1, 2, 3, 4, 5}, {6, 7}, {8, 9}, {10, 11, 12, 13}}},
+		// neighbours are collapsed one after another (coll = seq)
+		{level(quarters, 0, 1, 1, 2, 2), [][]int{{0, 1, 2, 3, 4, 5}, {6, 7, 8, 9}, {10, 11, 12, 13}}},
+		// the merged cluster is collapsed with its next neighbour only if the MSS is reduced
+		{level(pieces, 0, 1, 1, 1, 2), [][]int{{0, 1, 2, 3, 4, 5}, {6, 7, 8}, {9}, {10, 11, 12, 13}}},
+	}
+	for i, test := range tests {
+		collapsed := h.collapse(test.level)
+		members := make([][]int, len(collapsed))
+		for kk, cl := range collapsed {
+			members[kk] = cl.members
+		}
+		if !reflect.DeepEqual(members, test.members) {
+			t.Errorf("#%d Hopach.collapse(...) got %v, want %v", i, members, test.members)
+		}
+	}
+}
+
+func TestCorrelationOrder(t *testing.T) {
+	// data points on a line at 0, 3, 1, 2
+	d := NewDistances(Matrix{{0}, {3}, {1}, {2}}, Euclidean)
+	elements := []int{0, 1, 2, 3}
+	// all orderings are considered if maxK >= 4; otherwise, the greedy ordering is used
+	for _, maxK := range []int{9, 3} {
+		order := correlationOrder(d, elements, maxK)
+		if !reflect.DeepEqual(order, []int{0, 2, 3, 1}) && !reflect.DeepEqual(order, []int{1, 3, 2, 0}) {
+			t.Errorf("correlationOrder(..., %d) got %v, want [0 2 3 1] or its reverse", maxK, order)
+		}
+	}
+}
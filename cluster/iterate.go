@@ -0,0 +1,58 @@
+package cluster
+
+import (
+	"context"
+	"math"
+)
+
+// IterationFunc is called after each iteration of an iterative clusterer with
+// the iteration number (starting at 1), the cost after the iteration, and the
+// number of data points that were reassigned to a different cluster.
+type IterationFunc func(iter int, cost float64, reassigned int)
+
+// ContextClusterer is implemented by iterative clusterers that can be cancelled.
+type ContextClusterer interface {
+	Clusterer
+	// ClusterContext clusters data points into k clusters, unless ctx is done
+	// before convergence, in which case ctx.Err() is returned.
+	ClusterContext(ctx context.Context, k int) (*Classes, error)
+}
+
+// iteration holds the settings of the expectation-maximization loop
+type iteration struct {
+	// maximum number of iterations, unlimited if 0
+	maxIter int
+	// relative decrease of the cost below which the loop is stopped, disabled if 0
+	tolerance float64
+	// called after each iteration, if not nil
+	callback IterationFunc
+	// cost and number of reassigned data points after each iteration
+	progress func() (cost float64, reassigned int)
+}
+
+// iterate alternates the expectation and maximization steps until the
+// expectation step converges, the maximum number of iterations is reached,
+// the cost decreases by no more than the tolerance, or ctx is done.
+// Returns the number of iterations performed.
+func (it iteration) iterate(ctx context.Context, expectation func() bool, maximization func()) (i int, err error) {
+	prev := math.Inf(1)
+	for {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		if expectation() || (it.maxIter != 0 && i >= it.maxIter) {
+			return
+		}
+		maximization()
+		i++
+
+		cost, reassigned := it.progress()
+		if it.callback != nil {
+			it.callback(i, cost, reassigned)
+		}
+		if it.tolerance > 0 && !math.IsInf(prev, 1) && prev - cost <= it.tolerance * math.Abs(prev) {
+			return
+		}
+		prev = cost
+	}
+}
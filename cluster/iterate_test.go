@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+)
+
+var iterateX = Matrix{
+	{-10, -20}, {-10, -18}, { -8, -18}, { -8, -20}, { -9, -19},
+	{ 10,  20}, { 10,  18}, {  8,  18}, {  8,  20}, {  9,  19},
+	{ 10, -20}, { 10, -18}, {  8, -18}, {  8, -20}, {  9, -19},
+}
+
+func TestClusterContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	clusterers := []ContextClusterer{
+		NewKMeans(iterateX, Euclidean),
+		NewKMedians(iterateX, Manhattan),
+		NewKMedoids(iterateX, Euclidean, nil),
+		NewKMeansElkan(iterateX, Euclidean),
+		NewKMeansHamerly(iterateX, Euclidean),
+		NewMiniBatchKMeans(iterateX, Euclidean, 4),
+		&MixModel{X: iterateX},
+	}
+	for i, c := range clusterers {
+		if classes, err := c.ClusterContext(ctx, 3); classes != nil || err != context.Canceled {
+			t.Errorf("#%d %T.ClusterContext(cancelled, 3) got %v, %v, want nil, %v", i, c, classes, err, context.Canceled)
+		}
+	}
+}
+
+func TestOnIteration(t *testing.T) {
+	c := NewKMeans(iterateX, Euclidean)
+	c.Seeding, c.InitialCenters = UserSeeding, Matrix{{-10, -20}, {-10, -18}, {-8, -18}}
+	var iters []int
+	var costs []float64
+	c.OnIteration = func(iter int, cost float64, reassigned int) {
+		iters = append(iters, iter)
+		costs = append(costs, cost)
+		if iter == 1 && reassigned == 0 {
+			t.Errorf("KMeans.OnIteration(1, ...) got 0 reassigned data points")
+		}
+	}
+	classes := c.Cluster(3)
+	if len(iters) == 0 {
+		t.Fatalf("KMeans.OnIteration was not called")
+	}
+	for i := range iters {
+		if iters[i] != i+1 {
+			t.Errorf("KMeans.OnIteration got iteration %d, want %d", iters[i], i+1)
+		}
+		if i > 0 && costs[i] > costs[i-1] {
+			t.Errorf("KMeans.OnIteration got increasing costs %v", costs)
+		}
+	}
+	if last := costs[len(costs)-1]; last != classes.Cost {
+		t.Errorf("KMeans.OnIteration got final cost %v, want %v", last, classes.Cost)
+	}
+}
+
+func TestTolerance(t *testing.T) {
+	c := NewKMeans(iterateX, Euclidean)
+	c.Seeding, c.InitialCenters = UserSeeding, Matrix{{-10, -20}, {-10, -18}, {-8, -18}}
+	// any decrease is within the tolerance: stop after the second iteration
+	c.Tolerance = 1
+	n := 0
+	c.OnIteration = func(int, float64, int) { n++ }
+	c.Cluster(3)
+	if n != 2 {
+		t.Errorf("KMeans.Cluster(3) with Tolerance 1 got %d iterations, want 2", n)
+	}
+}
+
+func TestClusterContextCancelledInCallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &MixModel{X: iterateX}
+	c.OnIteration = func(int, float64, int) { cancel() }
+	if _, err := c.ClusterContext(ctx, 3); err != context.Canceled {
+		t.Errorf("MixModel.ClusterContext(...) got %v, want %v", err, context.Canceled)
+	}
+}
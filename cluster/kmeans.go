@@ -1,14 +1,13 @@
 package cluster
 
 import (
+	"context"
 	"math/rand"
+	"sync/atomic"
 	"github.com/NullHypothesis/mlgo"
 )
 
 // TODO Make repeat runs internal to KMeans, KMedians, and KMedoids
-// FIXME KMeans may initialize to 'duplicate' data points when there are ties,
-//       which will result in fewer than k clusters
-// FIXME KMeans may also return fewer than k clusters when a centroid loses all members during expectation
 
 type KMeans struct {
 	// Matrix of data points
@@ -18,7 +17,7 @@ type KMeans struct {
 	// number of clusters
 	K int
 	// Distances between data points [m x m]
-	D *Distances
+	D DistanceProvider
 	// Matrix of centroids	
 	Centers Matrix
 	// Total distance of members to each centroid
@@ -29,8 +28,32 @@ type KMeans struct {
 	Cost float64
 	// Maximum number of iterations
 	MaxIter int
+	// Relative decrease of the cost below which iterations are stopped; disabled if 0
+	Tolerance float64
+	// Called after each iteration, if not nil
+	OnIteration IterationFunc
 	// ordered index of elements subset
 	Index []int
+	// Seeding strategy for the initial centers
+	Seeding Seeding
+	// Metric returns squared distances (e.g. EuclideanSq), which k-means++
+	// seeding uses as weights as they are; otherwise, distances are squared
+	SquaredMetric bool
+	// User-supplied initial centers, used with UserSeeding
+	InitialCenters Matrix
+	// Recovery strategy for clusters that lose all members
+	EmptyClusters EmptyClusterStrategy
+	// Number of clusters dropped by the DropEmpty strategy
+	Dropped int
+	// Maximum number of goroutines for the expectation and maximization steps;
+	// GOMAXPROCS is used if 0
+	Workers int
+	// Source of random numbers; the global source is used if nil
+	Source rand.Source
+	// random numbers drawn from Source
+	rng random
+	// number of data points reassigned in the last expectation step
+	reassigned int
 }
 
 func NewKMeans(X Matrix, metric MetricOp) *KMeans {
@@ -45,26 +68,38 @@ func NewKMeans(X Matrix, metric MetricOp) *KMeans {
 // Cluster runs the k-means algorithm once with random initialization
 // Returns the classification information
 func (c *KMeans) Cluster(k int) (classes *Classes) {
-	if c.X == nil || k >= c.Len() {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid,
+// or along with the classification information if a cluster is empty.
+func (c *KMeans) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before convergence.
+func (c *KMeans) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
 		return
 	}
-	c.K = k
+	c.K, c.Dropped = k, 0
 	c.initialize()
-	i := 0
-	for !c.expectation() && (c.MaxIter == 0 || i < c.MaxIter) {
-		c.maximization()
-		i++
+	i, err := c.iteration().iterate(ctx, c.expectation, c.maximization)
+	if err != nil {
+		return nil, err
 	}
-	if i == 0 {
-		// convergence is achieved right after initialization...
-		// run maximization at least once to calculate cost
+	if i == 0 || c.hasEmpty() {
+		// convergence is achieved right after initialization,
+		// or a cluster lost all members in the last expectation...
+		// run maximization to recover empty clusters and calculate cost
 		c.maximization()
 	}
 
 	// copy classifcation information
-	classes = &Classes{
-		make([]int, c.Len()), k, c.Cost}
-	copy(classes.Index, c.Clusters)
+	// N.B. c.K is less than k if empty clusters were dropped
+	classes = c.classes()
+	err = checkEmpty(classes)
 
 	return
 }
@@ -73,89 +108,187 @@ func (c *KMeans) Len() int {
 	return len(c.Index)
 }
 
-func (c *KMeans) Segregations(classes *Classes) (S Matrix) {
+// check returns an error if the data points are invalid, or if k is not in [1, m)
+func (c *KMeans) check(k int) error {
+	if c.X == nil {
+		return ErrNoData
+	}
+	if err := checkData(c.X, c.Index); err != nil {
+		return err
+	}
+	if c.Seeding == UserSeeding {
+		// metrics do not report centers of different dimensions
+		n := len(c.X[c.Index[0]])
+		for i, x := range c.InitialCenters {
+			if len(x) != n {
+				return &DimensionError{i, len(x), n}
+			}
+			if err := checkVector(x, i); err != nil {
+				return err
+			}
+		}
+	}
+	return checkK(k, c.Len() - 1, c.Len())
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+// The fitted model is copied and data points and distances are shared; the copy
+// draws random numbers from its own source, which is seeded by the source of c.
+func (c *KMeans) Clone() Cloner {
+	return c.clone()
+}
+
+func (c *KMeans) clone() *KMeans {
+	d := *c
+	d.Centers = Matrix(mlgo.Matrix(c.Centers).Copied())
+	d.Errors = append(Vector(nil), c.Errors...)
+	d.Clusters = append([]int(nil), c.Clusters...)
+	d.Source = deriveSource(c.Source)
+	d.rng = newRandom(d.Source)
+	return &d
+}
+
+// iteration returns the settings of the expectation-maximization loop
+func (c *KMeans) iteration() iteration {
+	return iteration{
+		maxIter: c.MaxIter,
+		tolerance: c.Tolerance,
+		callback: c.OnIteration,
+		progress: func() (float64, int) { return c.Cost, c.reassigned },
+	}
+}
+
+// classes returns a copy of the classification information
+func (c *KMeans) classes() *Classes {
+	classes := &Classes{
+		make([]int, c.Len()), c.K, c.Cost, make(Vector, len(c.Errors)) }
+	copy(classes.Index, c.Clusters)
+	copy(classes.Errors, c.Errors)
+	return classes
+}
+
+// totalError returns the sum of the errors of all clusters,
+// summed in order s.t. the cost does not depend on scheduling
+func (c *KMeans) totalError() (J float64) {
+	for _, e := range c.Errors {
+		J += e
+	}
+	return
+}
+
+// SetSource sets the source of random numbers used for seeding.
+func (c *KMeans) SetSource(src rand.Source) {
+	c.Source = src
+}
+
+// Predict returns the index of the nearest fitted center for each data point of X.
+func (c *KMeans) Predict(X Matrix) (p Partitions) {
+	S := c.Transform(X)
+	p = make(Partitions, len(X))
+	for i := range S {
+		min := maxValue
+		for ii, d := range S[i] {
+			if d < min {
+				p[i], min = ii, d
+			}
+		}
+	}
+	return
+}
+
+// Transform returns the distances from each data point of X to each fitted center.
+func (c *KMeans) Transform(X Matrix) Matrix {
+	return SegregationsFromCenters(X, c.Centers, c.Metric)
+}
+
+// Distances returns the distances between the data points,
+// calculating them if necessary.
+func (c *KMeans) Distances() DistanceProvider {
 	if c.D == nil {
-		c.D = NewDistances(c.X, c.Metric)
-		c.D.index = c.Index
+		c.D = NewDistances(c.X, c.Metric).Subset(c.Index)
 	}
-	S = Segregations(c.D, classes)
+	return c.D
+}
+
+func (c *KMeans) Segregations(classes *Classes) (S Matrix) {
+	S = Segregations(c.Distances(), classes)
 	return
 }
 
+// Subset returns an instance for the subset of data points specified by index,
+// which indexes the data points of c (not necessarily all of X).
 func (c *KMeans) Subset(index []int) Splitter {
 	// to avoid the subset instances having different instances of D, initialize D now
 	// (if D is initialized in the subset d and subsequently initialized in c, d.D and c.D will be different instances)
-	if c.D == nil {
-		c.D = NewDistances(c.X, c.Metric)
-	}
-	D := c.D.Subset(index)
+	D := c.Distances().Subset(index)
 	// create shallow copy of original instance, with new index and D 
 	d := &KMeans{
 		X:      c.X,
 		Metric: c.Metric,
-		Index: index,
+		Index: Permute(c.Index, index),
 		D: D,
+		MaxIter: c.MaxIter,
+		Tolerance: c.Tolerance,
+		OnIteration: c.OnIteration,
+		Seeding: c.Seeding,
+		SquaredMetric: c.SquaredMetric,
+		InitialCenters: c.InitialCenters,
+		EmptyClusters: c.EmptyClusters,
+		Workers: c.Workers,
+		// subsets may be clustered concurrently: each draws from its own source
+		Source: deriveSource(c.Source),
 	}
 	return d
 }
 
-// FIXME To deal with replicate data elements, ensure that the selected centers have distinct values (data permitting)
-// initialize the cluster centroids by randomly selecting data points
+// initialize the cluster centroids using the seeding strategy
 func (c *KMeans) initialize() {
 	c.Centers, c.Errors = make(Matrix, c.K), make(Vector, c.K)
 
 	m := c.Len()
 	c.Clusters = make([]int, m)
 
-	activeSet := NewActiveSet(m)
-	for k, _ := range c.Centers {
-		i := activeSet.Get( rand.Intn(activeSet.Len()) )
-		x := c.X[c.Index[i]]
-		activeSet.Remove(i)
-		// copy data vector
-		c.Centers[k] = make(Vector, len(x))
-		copy(c.Centers[k], x)
-	}
+	c.rng = newRandom(c.Source)
+	c.seed()
 }
 
 // expectation step: assign data points to cluster centroids
 // Returns whether the algorithm has converged
 func (c *KMeans) expectation() (converged bool) {
-	// find the centroids that is closest to the current data point
-	assign := func(i int, chClusters chan int) {
-		clusters, min := 0, maxValue
-		// find the center with the minimum distance
-		for ii := 0; ii < len(c.Centers); ii++ {
-			distance := c.Metric(c.X[i], c.Centers[ii])
-			if distance < min {
-				clusters, min = ii, distance
+	// assign each data point of the chunk to the closest centroid
+	var reassigned int64
+	assign := func(start, end int) {
+		n := 0
+		for i := start; i < end; i++ {
+			clusters, min := 0, maxValue
+			// find the center with the minimum distance
+			for ii := 0; ii < len(c.Centers); ii++ {
+				distance := c.Metric(c.X[c.Index[i]], c.Centers[ii])
+				if distance < min {
+					clusters, min = ii, distance
+				}
+			}
+			if c.Clusters[i] != clusters {
+				c.Clusters[i] = clusters
+				n++
 			}
 		}
-		chClusters <- clusters
-	}
-
-	// process examples concurrently
-	ch := make(chan int)
-	for _, i := range c.Index {
-		go assign(i, ch)
+		atomic.AddInt64(&reassigned, int64(n))
 	}
 
-	// collect results
-	converged = true
-	for i, _ := range c.Index {
-		if clusters := <-ch; c.Clusters[i] != clusters {
-			c.Clusters[i] = clusters
-			converged = false
-		}
-	}
+	// process chunks of examples concurrently
+	parallel(c.Len(), c.Workers, assign)
 
-	return
+	c.reassigned = int(reassigned)
+	return reassigned == 0
 }
 
 // maximization step: move cluster centers to centroids of data points
 func (c *KMeans) maximization() {
+	c.recoverEmpty()
+
 	// move the center of cluster_ii to the mean
-	move := func(ii int, chCost chan float64) {
+	move := func(ii int) {
 		center := c.Centers[ii]
 
 		// zero the coordinates
@@ -190,20 +323,15 @@ func (c *KMeans) maximization() {
 		}
 
 		c.Errors[ii] = cost
-		chCost <- cost
 	}
 
 	// process cluster centers concurrently
-	ch := make(chan float64)
-	for ii, _ := range c.Centers {
-		go move(ii, ch)
-	}
+	parallel(len(c.Centers), c.Workers, func(start, end int) {
+		for ii := start; ii < end; ii++ {
+			move(ii)
+		}
+	})
 
-	// collect results
-	J := 0.0
-	for ii := 0; ii < len(c.Centers); ii++ {
-		J += <-ch
-	}
-	c.Cost = J / float64(c.Len())
+	c.Cost = c.totalError() / float64(c.Len())
 
 }
@@ -0,0 +1,55 @@
+package cluster
+
+// Helpers for k-means variants that use the triangle inequality to bound
+// the distances between data points and centers (Elkan, Hamerly).
+// The bounds are only valid if the metric satisfies the triangle inequality
+// (e.g. Euclidean, but not EuclideanSq).
+
+// boundSlack guards the bounds against rounding errors: a distance computation
+// is only skipped if the bound is exceeded by a relative margin, s.t. the
+// assignments are exactly the same as those of KMeans.
+const boundSlack = 1e-10
+
+// exceeds returns whether bound is strictly greater than upper, with slack.
+func exceeds(bound, upper float64) bool {
+	return bound > upper * (1 + boundSlack)
+}
+
+// centerDistances returns the distances between all pairs of centers,
+// and half the distance from each center to its nearest other center.
+func (c *KMeans) centerDistances() (dcc Matrix, s Vector) {
+	k := len(c.Centers)
+	dcc, s = make(Matrix, k), make(Vector, k)
+	for a := range dcc {
+		dcc[a] = make(Vector, k)
+		s[a] = maxValue
+	}
+	for a := 0; a < k; a++ {
+		for b := a+1; b < k; b++ {
+			d := c.Metric(c.Centers[a], c.Centers[b])
+			dcc[a][b], dcc[b][a] = d, d
+			if d / 2 < s[a] { s[a] = d / 2 }
+			if d / 2 < s[b] { s[b] = d / 2 }
+		}
+	}
+	return
+}
+
+// drifts returns the distance that each center moved from the old centers.
+func (c *KMeans) drifts(old Matrix) (delta Vector) {
+	delta = make(Vector, len(c.Centers))
+	for ii := range c.Centers {
+		delta[ii] = c.Metric(old[ii], c.Centers[ii])
+	}
+	return
+}
+
+// copyCenters returns a copy of the centers.
+func (c *KMeans) copyCenters() Matrix {
+	old := make(Matrix, len(c.Centers))
+	for ii, center := range c.Centers {
+		old[ii] = make(Vector, len(center))
+		copy(old[ii], center)
+	}
+	return old
+}
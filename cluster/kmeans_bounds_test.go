@@ -0,0 +1,56 @@
+package cluster
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestKMeansAccelerated(t *testing.T) {
+	const m, n, k = 400, 4, 8
+	r := rand.New(rand.NewSource(1))
+	x := make(Matrix, m)
+	for i := range x {
+		x[i] = make(Vector, n)
+		// points around k well-spread means
+		mean := float64(i % k) * 3
+		for j := range x[i] {
+			x[i][j] = mean + r.NormFloat64()
+		}
+	}
+	initial := Matrix(x[:k])
+
+	c := NewKMeans(x, Euclidean)
+	c.Seeding, c.InitialCenters = UserSeeding, initial
+	want := c.Cluster(k)
+
+	elkan := NewKMeansElkan(x, Euclidean)
+	hamerly := NewKMeansHamerly(x, Euclidean)
+	elkan.Seeding, elkan.InitialCenters = UserSeeding, initial
+	hamerly.Seeding, hamerly.InitialCenters = UserSeeding, initial
+
+	for _, test := range []struct {
+		c Clusterer
+		centers *Matrix
+		avoided *int
+	}{
+		{elkan, &elkan.Centers, &elkan.Avoided},
+		{hamerly, &hamerly.Centers, &hamerly.Avoided},
+	} {
+		got := test.c.Cluster(k)
+		for i := range want.Index {
+			if got.Index[i] != want.Index[i] {
+				t.Fatalf("%T.Cluster(%d) got %v, want %v", test.c, k, got.Index, want.Index)
+			}
+		}
+		for ii := range c.Centers {
+			for j := range c.Centers[ii] {
+				if (*test.centers)[ii][j] != c.Centers[ii][j] {
+					t.Fatalf("%T.Cluster(%d) got centers %v, want %v", test.c, k, *test.centers, c.Centers)
+				}
+			}
+		}
+		if *test.avoided <= 0 {
+			t.Errorf("%T.Cluster(%d) avoided %d distance evaluations", test.c, k, *test.avoided)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package cluster
+
+import (
+	"context"
+)
+
+// KMeansElkan is k-means accelerated by Elkan's algorithm (2003).
+// An upper bound on the distance to the assigned center and a lower bound on
+// the distance to every center are kept for each data point, s.t. most
+// distance computations are skipped. The assignments are the same as those of
+// KMeans, provided that the metric satisfies the triangle inequality.
+type KMeansElkan struct {
+	KMeans
+	// Number of distance evaluations performed
+	Evaluations int
+	// Number of distance evaluations avoided, compared to KMeans
+	Avoided int
+	// upper bound on the distance of each data point to its center [m]
+	upper Vector
+	// lower bounds on the distances of each data point to each center [m x k]
+	lower Matrix
+}
+
+func NewKMeansElkan(X Matrix, metric MetricOp) *KMeansElkan {
+	return &KMeansElkan{ KMeans: *NewKMeans(X, metric) }
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+// The bounds are not copied.
+func (c *KMeansElkan) Clone() Cloner {
+	return &KMeansElkan{ KMeans: *c.KMeans.clone() }
+}
+
+// Cluster runs Elkan's k-means algorithm once with random initialization
+// Returns the classification information
+// N.B. Must explicitly override KMeans.Cluster s.t. the bounded expectation is called.
+func (c *KMeansElkan) Cluster(k int) (classes *Classes) {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid,
+// or along with the classification information if a cluster is empty.
+func (c *KMeansElkan) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before convergence.
+func (c *KMeansElkan) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
+		return
+	}
+	c.K, c.Dropped = k, 0
+	c.Evaluations, c.Avoided = 0, 0
+	c.initialize()
+	c.lower = nil
+	i, err := c.iteration().iterate(ctx, c.expectation, c.maximization)
+	if err != nil {
+		return nil, err
+	}
+	if i == 0 || c.hasEmpty() {
+		// convergence is achieved right after initialization,
+		// or a cluster lost all members in the last expectation...
+		// run maximization to recover empty clusters and calculate cost
+		c.maximization()
+	}
+
+	// copy classifcation information
+	classes = c.classes()
+	err = checkEmpty(classes)
+
+	return
+}
+
+// expectation step: assign data points to the nearest centers, skipping
+// centers that cannot be nearer according to the bounds
+// Returns whether the algorithm has converged
+func (c *KMeansElkan) expectation() (converged bool) {
+	m, k := c.Len(), len(c.Centers)
+	evaluations := c.Evaluations
+	defer func() {
+		c.Avoided += m * k - (c.Evaluations - evaluations)
+	}()
+
+	converged = true
+	c.reassigned = 0
+
+	if c.lower == nil {
+		// no bounds yet: compute all distances
+		c.upper, c.lower = make(Vector, m), make(Matrix, m)
+		for i := 0; i < m; i++ {
+			c.lower[i] = make(Vector, k)
+			x := c.X[c.Index[i]]
+			a, min := 0, maxValue
+			for ii := 0; ii < k; ii++ {
+				d := c.Metric(x, c.Centers[ii])
+				c.lower[i][ii] = d
+				if d < min {
+					a, min = ii, d
+				}
+			}
+			c.Evaluations += k
+			c.upper[i] = min
+			if c.Clusters[i] != a {
+				c.Clusters[i] = a
+				c.reassigned++
+				converged = false
+			}
+		}
+		return
+	}
+
+	dcc, s := c.centerDistances()
+	c.Evaluations += k * (k - 1) / 2
+
+	for i := 0; i < m; i++ {
+		a, u := c.Clusters[i], c.upper[i]
+		if exceeds(s[a], u) {
+			// all other centers are farther than half the distance to the nearest center
+			continue
+		}
+		x := c.X[c.Index[i]]
+		tight := false
+		for ii := 0; ii < k; ii++ {
+			if ii == a || exceeds(c.lower[i][ii], u) || exceeds(dcc[a][ii] / 2, u) {
+				continue
+			}
+			if !tight {
+				// tighten the upper bound
+				u = c.Metric(x, c.Centers[a])
+				c.lower[i][a] = u
+				c.Evaluations++
+				tight = true
+				if exceeds(c.lower[i][ii], u) || exceeds(dcc[a][ii] / 2, u) {
+					continue
+				}
+			}
+			d := c.Metric(x, c.Centers[ii])
+			c.lower[i][ii] = d
+			c.Evaluations++
+			// in case of ties, the center with the lower index is chosen, as in KMeans
+			if d < u || (d == u && ii < a) {
+				a, u = ii, d
+			}
+		}
+		c.upper[i] = u
+		if c.Clusters[i] != a {
+			c.Clusters[i] = a
+			c.reassigned++
+			converged = false
+		}
+	}
+
+	return
+}
+
+// maximization step: move cluster centers to centroids of data points,
+// and update the bounds by the distances that the centers moved
+func (c *KMeansElkan) maximization() {
+	old := c.copyCenters()
+	// empty cluster recovery reassigns data points: bounds must be recomputed
+	reset := c.hasEmpty()
+
+	c.KMeans.maximization()
+
+	if reset || c.lower == nil || len(old) != len(c.Centers) {
+		c.lower = nil
+		return
+	}
+
+	delta := c.drifts(old)
+	c.Evaluations += len(delta)
+	for i := range c.lower {
+		c.upper[i] += delta[c.Clusters[i]]
+		for ii, d := range delta {
+			if c.lower[i][ii] -= d; c.lower[i][ii] < 0 {
+				c.lower[i][ii] = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package cluster
+
+import (
+	"context"
+)
+
+// KMeansHamerly is k-means accelerated by Hamerly's algorithm (2010).
+// An upper bound on the distance to the assigned center and a single lower
+// bound on the distance to the second nearest center are kept for each data
+// point, which requires less memory than Elkan's algorithm for large k.
+// The assignments are the same as those of KMeans, provided that the metric
+// satisfies the triangle inequality.
+type KMeansHamerly struct {
+	KMeans
+	// Number of distance evaluations performed
+	Evaluations int
+	// Number of distance evaluations avoided, compared to KMeans
+	Avoided int
+	// upper bound on the distance of each data point to its center [m]
+	upper Vector
+	// lower bound on the distance of each data point to its second nearest center [m]
+	lower Vector
+}
+
+func NewKMeansHamerly(X Matrix, metric MetricOp) *KMeansHamerly {
+	return &KMeansHamerly{ KMeans: *NewKMeans(X, metric) }
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+// The bounds are not copied.
+func (c *KMeansHamerly) Clone() Cloner {
+	return &KMeansHamerly{ KMeans: *c.KMeans.clone() }
+}
+
+// Cluster runs Hamerly's k-means algorithm once with random initialization
+// Returns the classification information
+// N.B. Must explicitly override KMeans.Cluster s.t. the bounded expectation is called.
+func (c *KMeansHamerly) Cluster(k int) (classes *Classes) {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid,
+// or along with the classification information if a cluster is empty.
+func (c *KMeansHamerly) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before convergence.
+func (c *KMeansHamerly) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
+		return
+	}
+	c.K, c.Dropped = k, 0
+	c.Evaluations, c.Avoided = 0, 0
+	c.initialize()
+	c.lower = nil
+	i, err := c.iteration().iterate(ctx, c.expectation, c.maximization)
+	if err != nil {
+		return nil, err
+	}
+	if i == 0 || c.hasEmpty() {
+		// convergence is achieved right after initialization,
+		// or a cluster lost all members in the last expectation...
+		// run maximization to recover empty clusters and calculate cost
+		c.maximization()
+	}
+
+	// copy classifcation information
+	classes = c.classes()
+	err = checkEmpty(classes)
+
+	return
+}
+
+// expectation step: assign data points to the nearest centers, skipping
+// data points whose bounds show that the assignment cannot change
+// Returns whether the algorithm has converged
+func (c *KMeansHamerly) expectation() (converged bool) {
+	m, k := c.Len(), len(c.Centers)
+	evaluations := c.Evaluations
+	defer func() {
+		c.Avoided += m * k - (c.Evaluations - evaluations)
+	}()
+
+	var s Vector
+	if c.lower == nil {
+		// no bounds yet: compute all distances
+		c.upper, c.lower = make(Vector, m), make(Vector, m)
+	} else {
+		_, s = c.centerDistances()
+		c.Evaluations += k * (k - 1) / 2
+	}
+
+	converged = true
+	c.reassigned = 0
+	for i := 0; i < m; i++ {
+		a := c.Clusters[i]
+		x := c.X[c.Index[i]]
+
+		if s != nil {
+			z := c.lower[i]
+			if s[a] > z {
+				z = s[a]
+			}
+			if exceeds(z, c.upper[i]) {
+				continue
+			}
+			// tighten the upper bound
+			c.upper[i] = c.Metric(x, c.Centers[a])
+			c.Evaluations++
+			if exceeds(z, c.upper[i]) {
+				continue
+			}
+		}
+
+		// find the nearest and second nearest centers
+		// in case of ties, the center with the lower index is chosen, as in KMeans
+		a, min, second := 0, maxValue, maxValue
+		for ii := 0; ii < k; ii++ {
+			d := c.Metric(x, c.Centers[ii])
+			if d < min {
+				a, min, second = ii, d, min
+			} else if d < second {
+				second = d
+			}
+		}
+		c.Evaluations += k
+		c.upper[i], c.lower[i] = min, second
+		if c.Clusters[i] != a {
+			c.Clusters[i] = a
+			c.reassigned++
+			converged = false
+		}
+	}
+
+	return
+}
+
+// maximization step: move cluster centers to centroids of data points,
+// and update the bounds by the distances that the centers moved
+func (c *KMeansHamerly) maximization() {
+	old := c.copyCenters()
+	// empty cluster recovery reassigns data points: bounds must be recomputed
+	reset := c.hasEmpty()
+
+	c.KMeans.maximization()
+
+	if reset || c.lower == nil || len(old) != len(c.Centers) {
+		c.lower = nil
+		return
+	}
+
+	delta := c.drifts(old)
+	c.Evaluations += len(delta)
+	max := 0.0
+	for _, d := range delta {
+		if d > max {
+			max = d
+		}
+	}
+	for i := range c.lower {
+		c.upper[i] += delta[c.Clusters[i]]
+		if c.lower[i] -= max; c.lower[i] < 0 {
+			c.lower[i] = 0
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"sort"
 )
 
@@ -12,33 +13,50 @@ func NewKMedians(X Matrix, metric MetricOp) *KMedians {
 	return &KMedians{ KMeans: *NewKMeans(X, metric) }
 }
 
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+func (c *KMedians) Clone() Cloner {
+	return &KMedians{ KMeans: *c.KMeans.clone() }
+}
+
 // Cluster runs the k-medians algorithm once with random initialization
 // Returns the classification information
 // N.B. Must explicitly override KMeans.Cluster s.t. KMedians.maximization is called
 // instead of KMeans.maximization.
 func (c *KMedians) Cluster(k int) (classes *Classes) {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid,
+// or along with the classification information if a cluster is empty.
+func (c *KMedians) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
 
-	if c.X == nil || k >= c.Len() {
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before convergence.
+func (c *KMedians) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+
+	if err = c.check(k); err != nil {
 		return
 	}
 
-	c.K = k
+	c.K, c.Dropped = k, 0
 	c.initialize()
-	i := 0
-	for !c.expectation() && (c.MaxIter == 0 || i < c.MaxIter) {
-		c.maximization()
-		i++
+	i, err := c.iteration().iterate(ctx, c.expectation, c.maximization)
+	if err != nil {
+		return nil, err
 	}
-	if i == 0 {
-		// convergence is achieved right after initialization...
-		// run maximization at least once to calculate cost
+	if i == 0 || c.hasEmpty() {
+		// convergence is achieved right after initialization,
+		// or a cluster lost all members in the last expectation...
+		// run maximization to recover empty clusters and calculate cost
 		c.maximization()
 	}
 
 	// copy classifcation information
-	classes = &Classes{
-		make([]int, c.Len()), k, c.Cost}
-	copy(classes.Index, c.Clusters)
+	// N.B. c.K is less than k if empty clusters were dropped
+	classes = c.classes()
+	err = checkEmpty(classes)
 
 	return
 }
@@ -47,8 +65,10 @@ func (c *KMedians) Cluster(k int) (classes *Classes) {
 // Calculate the median instead of mean;
 // total absolute deviation instead of total sum of squares
 func (c *KMedians) maximization() {
+	c.recoverEmpty()
+
 	// move cluster centroid_ii to the median
-	move := func(ii int, chCost chan float64) {
+	move := func(ii int) {
 		center := c.Centers[ii]
 		// hold coordinate of each dimension for each member
 		// members is a dimension by member matrix
@@ -85,21 +105,16 @@ func (c *KMedians) maximization() {
 		}
 
 		c.Errors[ii] = cost
-		chCost <- cost;
 	}
 
 	// process cluster center concurrently
-	ch := make(chan float64)
-	for ii, _ := range c.Centers {
-		go move(ii, ch)
-	}
+	parallel(len(c.Centers), c.Workers, func(start, end int) {
+		for ii := start; ii < end; ii++ {
+			move(ii)
+		}
+	})
 
-	// collect results
-	J := 0.0
-	for ii := 0; ii < len(c.Centers); ii++ {
-		J += <-ch;
-	}
-	c.Cost = J / float64( c.Len() )
+	c.Cost = c.totalError() / float64( c.Len() )
 }
 
 // find median
@@ -1,7 +1,9 @@
 package cluster
 
 import (
+	"context"
 	"sort"
+	"github.com/NullHypothesis/mlgo"
 )
 
 // TODO make KMedoids use Distances class
@@ -10,9 +12,22 @@ import (
 
 type KMedoids struct {
 	KMeans
+	// Search algorithm for the medoids
+	Algorithm MedoidAlgorithm
+	// Medoids as indices into X, s.t. Centers[ii] is X[Medoids[ii]]
+	Medoids []int
+	// medoids as indices into the data points of the subset (PAM)
+	medoids []int
+	// distances of each data point to its nearest and second nearest medoids (PAM)
+	dNearest, dSecond Vector
+	// exchanges of medoid and data point to be performed in the next SWAP step (PAM)
+	pending [][2]int
 }
 
-func NewKMedoids(X Matrix, metric MetricOp, distances *Distances) *KMedoids {
+// NewKMedoids returns a k-medoids clusterer for the data points of X.
+// X may be nil if the distances are given, e.g. by NewDistancesFromMatrix,
+// in which case Centers are not available and PAM must be used.
+func NewKMedoids(X Matrix, metric MetricOp, distances DistanceProvider) *KMedoids {
 	if distances == nil {
 		distances = NewDistances(X, metric)
 	}
@@ -20,21 +35,53 @@ func NewKMedoids(X Matrix, metric MetricOp, distances *Distances) *KMedoids {
 		KMeans: *NewKMeans(X, metric),
 	}
 	c.D = distances
+	if X == nil {
+		c.Index = mlgo.Range(0, distances.Len())
+	}
 	return c
 }
 
-// Cluster runs the k-medoids algorithm.
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+func (c *KMedoids) Clone() Cloner {
+	return &KMedoids{
+		KMeans: *c.KMeans.clone(),
+		Algorithm: c.Algorithm,
+		Medoids: append([]int(nil), c.Medoids...),
+		medoids: append([]int(nil), c.medoids...),
+		dNearest: append(Vector(nil), c.dNearest...),
+		dSecond: append(Vector(nil), c.dSecond...),
+	}
+}
+
+// Cluster runs the k-medoids algorithm specified by Algorithm (VoronoiIteration by default).
 // Returns the classification information.
 func (c *KMedoids) Cluster(k int) (classes *Classes) {
-	if c.X == nil || k >= c.Len() {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points, distances or k are invalid.
+func (c *KMedoids) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before convergence.
+func (c *KMedoids) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
 		return
 	}
+	if c.Algorithm != VoronoiIteration {
+		return c.clusterPAM(ctx, k)
+	}
+	if c.X == nil {
+		// Voronoi iteration assigns data points by their coordinates
+		return nil, ErrNoData
+	}
 	c.K = k
 	c.initialize()
-	i := 0
-	for !c.expectation() && (c.MaxIter == 0 || i < c.MaxIter) {
-		c.maximization()
-		i++
+	i, err := c.iteration().iterate(ctx, c.expectation, c.maximization)
+	if err != nil {
+		return nil, err
 	}
 	if i == 0 {
 		// convergence is achieved right after initialization...
@@ -43,17 +90,36 @@ func (c *KMedoids) Cluster(k int) (classes *Classes) {
 	}
 
 	// copy classifcation information
-	classes = &Classes{
-		make([]int, c.Len()), k, c.Cost }
-	copy(classes.Index, c.Clusters)
+	classes = c.classes()
 
 	return
 }
 
+// check returns an error if the data points or distances are invalid, or if k is not in [1, m)
+func (c *KMedoids) check(k int) error {
+	if c.X != nil {
+		if err := c.KMeans.check(k); err != nil {
+			return err
+		}
+	} else if c.D == nil {
+		return ErrNoData
+	}
+	if c.D != nil {
+		if err := checkDistances(c.D); err != nil {
+			return err
+		}
+	}
+	return checkK(k, c.Len() - 1, c.Len())
+}
+
 func (c *KMedoids) Subset(index []int) Splitter {
-	D := c.D.Subset(index)
+	var D DistanceProvider
+	if c.D != nil {
+		D = c.D.Subset(index)
+	}
 	return &KMedoids{
-		KMeans: KMeans{X: c.X, Metric: c.Metric, Index: index, D: D},
+		KMeans: KMeans{X: c.X, Metric: c.Metric, Index: Permute(c.Index, index), D: D, Workers: c.Workers},
+		Algorithm: c.Algorithm,
 	}
 }
 
@@ -77,24 +143,26 @@ func (c *KMedoids) initialize() {
 		}
 	}
 
-	// sum the normalized distances across all rows
+	// sum the normalized distances to each data point across all rows
 	p := make(pairs, m)
 	for i, _ := range(normalized) {
 		p[i].value = c.Index[i]
-		for _, x := range normalized[i] {
-			p[i].key += x
+		for j, x := range normalized[i] {
+			p[j].key += x
 		}
 	}
 
-	// sort the summed normalized distances
-	sort.Sort(p)
+	// sort the summed normalized distances, keeping the order of ties
+	sort.Stable(p)
 
 	c.Clusters = make([]int, c.Len())
 
 	// initialize centers
 	c.Centers, c.Errors = make(Matrix, c.K), make(Vector, c.K)
+	c.Medoids = make([]int, c.K)
 	for k, _ := range c.Centers {
 		// use the first k data points sorted by summed normalized distances
+		c.Medoids[k] = p[k].value
 		x := c.X[ p[k].value ]
 		c.Centers[k] = make(Vector, len(x))
 		copy(c.Centers[k], x)
@@ -105,7 +173,7 @@ func (c *KMedoids) initialize() {
 // s.t. total distance to the new medoid is minimized.
 func (c *KMedoids) maximization() {
 	// swap medoid
-	swap := func(ii int, chCost chan float64) {
+	swap := func(ii int) {
 		center := c.Centers[ii]
 
 		// gather members
@@ -120,7 +188,7 @@ func (c *KMedoids) maximization() {
 
 		if n == 0 {
 			// medoid has no member: terminate with 0 cost
-			chCost <- 0
+			c.Errors[ii] = 0
 			return
 		}
 		memberIdx = memberIdx[:n]
@@ -143,24 +211,20 @@ func (c *KMedoids) maximization() {
 				newCenter, min = memberIdx[i], d
 			}
 		}
-		copy(center, c.X[newCenter])
+		copy(center, c.X[ c.Index[newCenter] ])
+		c.Medoids[ii] = c.Index[newCenter]
 
 		// use the minimum total distance as the cost
 		c.Errors[ii] = min
-		chCost <- min
 	}
 
 	// process cluster center concurrently
-	ch := make(chan float64)
-	for ii, _ := range c.Centers {
-		go swap(ii, ch)
-	}
+	parallel(len(c.Centers), c.Workers, func(start, end int) {
+		for ii := start; ii < end; ii++ {
+			swap(ii)
+		}
+	})
 
-	// collect results
-	J := 0.0
-	for ii := 0; ii < len(c.Centers); ii++ {
-		J += <-ch;
-	}
-	c.Cost = J / float64( len(c.X) )
+	c.Cost = c.totalError() / float64( c.Len() )
 }
 
@@ -1,7 +1,9 @@
 package cluster
 
 import (
+	"math"
 	"testing"
+	"github.com/NullHypothesis/mlgo"
 )
 
 var kmedoidsTests = []struct {
@@ -45,3 +47,48 @@ func TestKMedoids(t *testing.T) {
 		}
 	}
 }
+
+func TestKMedoidsMedoids(t *testing.T) {
+	for i, test := range append(kmedoidsTests, pamTests...) {
+		for _, algorithm := range []MedoidAlgorithm{VoronoiIteration, PAM, FastPAM1, FastPAM2} {
+			c := NewKMedoids(test.x, test.metric, nil)
+			c.Algorithm = algorithm
+			classes := c.Cluster(test.k)
+			if len(c.Medoids) != test.k {
+				t.Fatalf("#%d KMedoids.Cluster(%d) with algorithm %d got medoids %v", i, test.k, algorithm, c.Medoids)
+			}
+			total := 0.0
+			for ii, j := range c.Medoids {
+				if !mlgo.Vector(test.x[j]).Equal(mlgo.Vector(c.Centers[ii])) {
+					t.Errorf("#%d KMedoids.Cluster(%d) with algorithm %d got medoid %d at %v, want %v", i, test.k, algorithm, j, test.x[j], c.Centers[ii])
+				}
+				total += classes.Errors[ii]
+			}
+			if math.Abs(total / float64(len(test.x)) - classes.Cost) > 1e-9 {
+				t.Errorf("#%d KMedoids.Cluster(%d) with algorithm %d got errors %v, want sum %v", i, test.k, algorithm, classes.Errors, classes.Cost * float64(len(test.x)))
+			}
+		}
+	}
+}
+
+func TestKMedoidsSubsetCost(t *testing.T) {
+	test := pamTests[1]
+	c := NewKMedoids(test.x, test.metric, nil)
+	c.Algorithm = PAM
+	// the first two groups
+	index := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	d := c.Subset(index).(*KMedoids)
+	classes := d.Cluster(2)
+	// each member is at distance 1 from the medoid at the center of its group
+	if classes.Cost != 0.8 {
+		t.Errorf("KMedoids.Subset(...).Cluster(2) got cost %v, want %v", classes.Cost, 0.8)
+	}
+	if !CoordinatesSetEqual(d.Centers, Matrix{{0, 0}, {10, 10}}) {
+		t.Errorf("KMedoids.Subset(...).Cluster(2) got centers %v", d.Centers)
+	}
+	for _, j := range d.Medoids {
+		if j != 0 && j != 5 {
+			t.Errorf("KMedoids.Subset(...).Cluster(2) got medoids %v, want [0 5]", d.Medoids)
+		}
+	}
+}
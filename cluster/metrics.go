@@ -1,21 +1,37 @@
 package cluster
 
 import (
+	"fmt"
 	"math"
+	"sort"
 )
 
+// MetricOp returns the distance between a and b. Metrics are called concurrently,
+// e.g. by NewDistancesWith, and must be safe for concurrent use.
+// The metrics of this package return 0 if a and b have different dimensions;
+// such points are only reported (by DimensionError) by Distance, the Fit methods
+// of the clusterers and NewHierarchical, which check the data points beforehand.
 type MetricOp func(a, b Vector) float64
 
+// Missing coordinates (NaN) are omitted by the metrics. As in R's dist, sums
+// over the remaining coordinates are rescaled by the proportion of coordinates
+// that are present in both points, and the distance is NaN if there are none.
+
 // EuclideanSq returns the Euclidean squared distance metric between points a and b
 func EuclideanSq(a, b Vector) (d float64) {
 	if len(a) != len(b) {
 		return
 	}
+	missing := 0
 	for i := 0; i < len(a); i++ {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			missing++
+			continue
+		}
 		t := b[i] - a[i]
 		d += t * t
 	}
-	return
+	return rescale(d, len(a), missing)
 }
 
 // Euclidean returns the Euclidean distance metric between points a and b
@@ -23,11 +39,16 @@ func Euclidean(a, b Vector) (d float64) {
 	if len(a) != len(b) {
 		return
 	}
+	missing := 0
 	for i := 0; i < len(a); i++ {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			missing++
+			continue
+		}
 		t := b[i] - a[i]
 		d += t * t
 	}
-	d = math.Sqrt(d)
+	d = math.Sqrt(rescale(d, len(a), missing))
 	return
 }
 
@@ -36,10 +57,15 @@ func Manhattan(a, b Vector) (d float64) {
 	if len(a) != len(b) {
 		return
 	}
+	missing := 0
 	for i := 0; i < len(a); i++ {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			missing++
+			continue
+		}
 		d += math.Abs(b[i] - a[i])
 	}
-	return
+	return rescale(d, len(a), missing)
 }
 
 // Chebyshev returns the Chebyshev distance metric between points a and b
@@ -47,12 +73,20 @@ func Chebyshev(a, b Vector) (d float64) {
 	if len(a) != len(b) {
 		return
 	}
+	missing := 0
 	for i := 0; i < len(a); i++ {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			missing++
+			continue
+		}
 		t := math.Abs(b[i] - a[i])
 		if t > d {
 			d = t
 		}
 	}
+	if missing > 0 && missing == len(a) {
+		return math.NaN()
+	}
 	return
 }
 
@@ -61,10 +95,359 @@ func Minkowski(a, b Vector, p float64) (d float64) {
 	if len(a) != len(b) {
 		return
 	}
+	missing := 0
 	for i := 0; i < len(a); i++ {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			missing++
+			continue
+		}
 		d += math.Pow(math.Abs(b[i]-a[i]), p)
 	}
-	d = math.Pow(d, 1/p)
+	d = math.Pow(rescale(d, len(a), missing), 1/p)
 	return
 }
 
+
+// MinkowskiP returns the Minkowski distance metric of order p
+func MinkowskiP(p float64) MetricOp {
+	return func(a, b Vector) float64 {
+		return Minkowski(a, b, p)
+	}
+}
+
+// Cosine returns the cosine distance between points a and b, i.e. 1 minus the
+// cosine of the angle between them. The distance of a zero vector to any
+// other vector is 1.
+func Cosine(a, b Vector) (d float64) {
+	if len(a) != len(b) {
+		return
+	}
+	a, b, ok := complete(a, b)
+	if !ok {
+		return math.NaN()
+	}
+	return 1 - similarity(a, b)
+}
+
+// Angular returns the angular distance between points a and b, i.e. the angle
+// between them divided by pi, which, unlike Cosine, satisfies the triangle inequality.
+func Angular(a, b Vector) (d float64) {
+	if len(a) != len(b) {
+		return
+	}
+	a, b, ok := complete(a, b)
+	if !ok {
+		return math.NaN()
+	}
+	return math.Acos(similarity(a, b)) / math.Pi
+}
+
+// Pearson returns the Pearson correlation distance between points a and b,
+// i.e. 1 minus their correlation coefficient, which ranges from 0 to 2.
+// Points whose coordinates are correlated are near, regardless of their scale
+// and offset (e.g. time series of the same shape).
+// The distance is NaN if the coordinates of a or b are constant, as the
+// correlation is undefined (NA in R).
+func Pearson(a, b Vector) (d float64) {
+	if len(a) != len(b) {
+		return
+	}
+	a, b, ok := complete(a, b)
+	if !ok || constant(a) || constant(b) {
+		return math.NaN()
+	}
+	return 1 - similarity(centered(a), centered(b))
+}
+
+// Spearman returns the Spearman correlation distance between points a and b,
+// i.e. the Pearson correlation distance between the ranks of their coordinates.
+// The distance is NaN if the coordinates of a or b are constant.
+func Spearman(a, b Vector) (d float64) {
+	if len(a) != len(b) {
+		return
+	}
+	a, b, ok := complete(a, b)
+	if !ok {
+		return math.NaN()
+	}
+	return Pearson(ranks(a), ranks(b))
+}
+
+// Canberra returns the Canberra distance between points a and b, i.e. the sum
+// of |a_i - b_i| / (|a_i| + |b_i|). This is the textbook form (as in SciPy);
+// R's dist divides by |a_i + b_i|, which differs for coordinates of opposite signs.
+// Coordinates that are zero in both points are treated as missing (as in R),
+// s.t. the distance is NaN if all coordinates are zero in both points.
+func Canberra(a, b Vector) (d float64) {
+	if len(a) != len(b) {
+		return
+	}
+	missing := 0
+	for i := 0; i < len(a); i++ {
+		s := math.Abs(a[i]) + math.Abs(b[i])
+		if math.IsNaN(s) || s == 0 {
+			missing++
+			continue
+		}
+		d += math.Abs(b[i] - a[i]) / s
+	}
+	return rescale(d, len(a), missing)
+}
+
+// BrayCurtis returns the Bray-Curtis dissimilarity between points a and b,
+// which is usually applied to non-negative coordinates (e.g. counts).
+func BrayCurtis(a, b Vector) (d float64) {
+	if len(a) != len(b) {
+		return
+	}
+	sum, missing := 0.0, 0
+	for i := 0; i < len(a); i++ {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			missing++
+			continue
+		}
+		d += math.Abs(b[i] - a[i])
+		sum += math.Abs(b[i] + a[i])
+	}
+	if missing > 0 && missing == len(a) {
+		return math.NaN()
+	}
+	if sum == 0 {
+		return 0
+	}
+	return d / sum
+}
+
+// Hamming returns the Hamming distance between points a and b,
+// i.e. the proportion of coordinates that differ.
+func Hamming(a, b Vector) (d float64) {
+	if len(a) != len(b) || len(a) == 0 {
+		return
+	}
+	n := 0
+	for i := 0; i < len(a); i++ {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			continue
+		}
+		n++
+		if a[i] != b[i] {
+			d++
+		}
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return d / float64(n)
+}
+
+// NewMahalanobis returns the Mahalanobis distance metric for data points with
+// the covariance matrix S, which must be invertible.
+// If coordinates are missing, the covariance matrix of the remaining
+// coordinates is inverted for each pair of points.
+func NewMahalanobis(S Matrix) (MetricOp, error) {
+	inv, err := inverse(S)
+	if err != nil {
+		return nil, err
+	}
+	return func(a, b Vector) (d float64) {
+		if len(a) != len(b) || len(a) != len(inv) {
+			return
+		}
+		n, sinv := len(a), inv
+		if present := presentCoordinates(a, b); len(present) < n {
+			if len(present) == 0 {
+				return math.NaN()
+			}
+			// covariance matrix of the present coordinates
+			sub := make(Matrix, len(present))
+			for i, pi := range present {
+				sub[i] = make(Vector, len(present))
+				for j, pj := range present {
+					sub[i][j] = S[pi][pj]
+				}
+			}
+			var err error
+			if sinv, err = inverse(sub); err != nil {
+				return math.NaN()
+			}
+			a, b, _ = complete(a, b)
+		}
+		for i := range sinv {
+			t := 0.0
+			for j := range sinv[i] {
+				t += sinv[i][j] * (b[j] - a[j])
+			}
+			d += (b[i] - a[i]) * t
+		}
+		d = rescale(d, n, n - len(sinv))
+		// guard against rounding errors
+		return math.Sqrt(math.Max(d, 0))
+	}, nil
+}
+
+// rescale returns the sum d over the coordinates that are present in both of
+// two points of dimension n to all coordinates, or NaN if all are missing
+func rescale(d float64, n, missing int) float64 {
+	if missing == 0 {
+		return d
+	}
+	if missing == n {
+		return math.NaN()
+	}
+	return d * float64(n) / float64(n - missing)
+}
+
+// presentCoordinates returns the indices of the coordinates that are present in both a and b
+func presentCoordinates(a, b Vector) (index []int) {
+	index = make([]int, 0, len(a))
+	for i := range a {
+		if !math.IsNaN(a[i]) && !math.IsNaN(b[i]) {
+			index = append(index, i)
+		}
+	}
+	return
+}
+
+// complete returns the coordinates of a and b that are present in both,
+// and whether there are any; a and b are returned if none are missing
+func complete(a, b Vector) (ca, cb Vector, ok bool) {
+	missing := 0
+	for i := range a {
+		if math.IsNaN(a[i]) || math.IsNaN(b[i]) {
+			missing++
+		}
+	}
+	if missing == 0 {
+		return a, b, true
+	}
+	ca, cb = make(Vector, 0, len(a) - missing), make(Vector, 0, len(a) - missing)
+	for i := range a {
+		if !math.IsNaN(a[i]) && !math.IsNaN(b[i]) {
+			ca, cb = append(ca, a[i]), append(cb, b[i])
+		}
+	}
+	return ca, cb, len(ca) > 0
+}
+
+// similarity returns the cosine of the angle between a and b
+func similarity(a, b Vector) float64 {
+	dot, na, nb := 0.0, 0.0, 0.0
+	for i := 0; i < len(a); i++ {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		if na == nb {
+			// both are zero vectors
+			return 1
+		}
+		return 0
+	}
+	// guard against rounding errors
+	return math.Max(-1, math.Min(1, dot / math.Sqrt(na * nb)))
+}
+
+// constant returns whether all coordinates of x are equal
+func constant(x Vector) bool {
+	for _, v := range x {
+		if v != x[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// centered returns a copy of x with its mean subtracted
+func centered(x Vector) (y Vector) {
+	mean := 0.0
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float64(len(x))
+	y = make(Vector, len(x))
+	for i, v := range x {
+		y[i] = v - mean
+	}
+	return
+}
+
+// ranks returns the ranks of the coordinates of x, starting at 1;
+// tied coordinates are assigned their average rank
+func ranks(x Vector) (r Vector) {
+	p := make(pairs, len(x))
+	for i, v := range x {
+		p[i] = pair{key: v, value: i}
+	}
+	sort.Stable(p)
+	r = make(Vector, len(x))
+	for i := 0; i < len(p); {
+		j := i + 1
+		for j < len(p) && p[j].key == p[i].key {
+			j++
+		}
+		rank := float64(i + j + 1) / 2
+		for ; i < j; i++ {
+			r[p[i].value] = rank
+		}
+	}
+	return
+}
+
+// inverse returns the inverse of the square matrix A by Gauss-Jordan elimination.
+// A is taken to be singular if a pivot is small relative to the maximum absolute
+// row sum of A, s.t. the result does not depend on the scale of A.
+func inverse(A Matrix) (inv Matrix, err error) {
+	n := len(A)
+	// augmented matrix [A | I]
+	M := make(Matrix, n)
+	norm := 0.0
+	for i := range M {
+		if len(A[i]) != n {
+			return nil, fmt.Errorf("cluster: covariance matrix row %d has %d columns, want %d", i, len(A[i]), n)
+		}
+		M[i] = make(Vector, 2 * n)
+		copy(M[i], A[i])
+		M[i][n + i] = 1
+		sum := 0.0
+		for _, v := range A[i] {
+			sum += math.Abs(v)
+		}
+		norm = math.Max(norm, sum)
+	}
+	epsilon := 1e-12 * norm
+
+	for col := 0; col < n; col++ {
+		// partial pivoting
+		pivot := col
+		for i := col + 1; i < n; i++ {
+			if math.Abs(M[i][col]) > math.Abs(M[pivot][col]) {
+				pivot = i
+			}
+		}
+		if math.Abs(M[pivot][col]) <= epsilon {
+			return nil, fmt.Errorf("cluster: covariance matrix is singular")
+		}
+		M[col], M[pivot] = M[pivot], M[col]
+
+		p := M[col][col]
+		for j := range M[col] {
+			M[col][j] /= p
+		}
+		for i := 0; i < n; i++ {
+			if i == col || M[i][col] == 0 {
+				continue
+			}
+			f := M[i][col]
+			for j := range M[i] {
+				M[i][j] -= f * M[col][j]
+			}
+		}
+	}
+
+	inv = make(Matrix, n)
+	for i := range inv {
+		inv[i] = M[i][n:]
+	}
+	return
+}
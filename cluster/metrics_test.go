@@ -0,0 +1,104 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+)
+
+var metricsTests = []struct {
+	name string
+	metric MetricOp
+	a, b Vector
+	d float64
+}{
+	{"Cosine", Cosine, Vector{1, 0}, Vector{0, 1}, 1},
+	{"Cosine", Cosine, Vector{1, 2, 3}, Vector{2, 4, 6}, 0},
+	{"Cosine", Cosine, Vector{0, 0}, Vector{0, 1}, 1},
+	{"Angular", Angular, Vector{1, 0}, Vector{0, 1}, 0.5},
+	{"Angular", Angular, Vector{1, 0}, Vector{-2, 0}, 1},
+	{"Pearson", Pearson, Vector{1, 2, 3}, Vector{12, 14, 16}, 0},
+	{"Pearson", Pearson, Vector{1, 2, 3}, Vector{3, 2, 1}, 2},
+	{"Spearman", Spearman, Vector{1, 2, 3, 4}, Vector{1, 4, 9, 16}, 0},
+	{"Spearman", Spearman, Vector{1, 2, 3}, Vector{1, 3, 2}, 0.5},
+	{"Spearman", Spearman, Vector{1, 1, 2}, Vector{5, 5, 7}, 0},
+	// the correlation of constant points is undefined
+	{"Pearson", Pearson, Vector{1, 1, 1}, Vector{1, 2, 3}, nan},
+	{"Pearson", Pearson, Vector{0.1, 0.1, 0.1}, Vector{0.1, 0.1, 0.1}, nan},
+	{"Spearman", Spearman, Vector{1, 2, 3}, Vector{4, 4, 4}, nan},
+	{"Canberra", Canberra, Vector{1, 2, 0}, Vector{2, 2, 0}, 0.5},
+	{"Canberra", Canberra, Vector{0, 0}, Vector{0, 0}, nan},
+	// the denominator is |a_i| + |b_i|, not |a_i + b_i| as in R (which would be 0 here)
+	{"Canberra", Canberra, Vector{1, -2, 3}, Vector{-1, 2, 1}, 2.5},
+	{"BrayCurtis", BrayCurtis, Vector{1, 2, 3}, Vector{3, 2, 1}, 1.0 / 3},
+	{"Hamming", Hamming, Vector{1, 2, 3}, Vector{1, 0, 3}, 1.0 / 3},
+	{"MinkowskiP(1)", MinkowskiP(1), Vector{0, 0}, Vector{3, 4}, 7},
+	{"MinkowskiP(2)", MinkowskiP(2), Vector{0, 0}, Vector{3, 4}, 5},
+	{"Cosine", Cosine, Vector{1, 2}, Vector{1}, 0},
+	// missing coordinates
+	{"EuclideanSq", EuclideanSq, Vector{1, nan, 3}, Vector{4, 2, 7}, 37.5},
+	{"Euclidean", Euclidean, Vector{1, nan, 3}, Vector{4, 2, 7}, math.Sqrt(37.5)},
+	{"Euclidean", Euclidean, Vector{nan, 2}, Vector{1, nan}, nan},
+	{"Manhattan", Manhattan, Vector{1, nan, 3}, Vector{4, 2, 7}, 10.5},
+	{"Chebyshev", Chebyshev, Vector{1, nan, 3}, Vector{4, 2, 7}, 4},
+	{"MinkowskiP(1)", MinkowskiP(1), Vector{1, 2, nan}, Vector{4, 6, 0}, 10.5},
+	{"Cosine", Cosine, Vector{1, nan, 0}, Vector{0, 5, 1}, 1},
+	{"Pearson", Pearson, Vector{1, 2, nan, 3}, Vector{2, 4, 1, 6}, 0},
+	{"Canberra", Canberra, Vector{1, nan, 2}, Vector{3, 5, 2}, 0.75},
+	{"Canberra", Canberra, Vector{1, nan, 0, 2}, Vector{3, 5, 0, 2}, 1},
+	{"BrayCurtis", BrayCurtis, Vector{1, nan, 3}, Vector{3, 9, 1}, 0.5},
+	{"Hamming", Hamming, Vector{1, nan, 3}, Vector{1, 2, 4}, 0.5},
+	{"Hamming", Hamming, Vector{nan}, Vector{1}, nan},
+}
+
+var nan = math.NaN()
+
+func TestMetrics(t *testing.T) {
+	for i, test := range metricsTests {
+		d := test.metric(test.a, test.b)
+		if math.IsNaN(test.d) != math.IsNaN(d) || math.Abs(d - test.d) > 1e-9 {
+			t.Errorf("#%d %s(%v, %v) got %v, want %v", i, test.name, test.a, test.b, d, test.d)
+		}
+	}
+}
+
+func TestMahalanobis(t *testing.T) {
+	metric, err := NewMahalanobis(Matrix{{4, 0}, {0, 1}})
+	if err != nil {
+		t.Fatalf("NewMahalanobis(...) returned error: %v", err)
+	}
+	if d := metric(Vector{0, 0}, Vector{2, 1}); math.Abs(d - math.Sqrt2) > 1e-12 {
+		t.Errorf("Mahalanobis(...) got %v, want %v", d, math.Sqrt2)
+	}
+
+	// the covariance of the present coordinates is used, and rescaled
+	if d := metric(Vector{0, nan}, Vector{2, 1}); math.Abs(d - math.Sqrt2) > 1e-12 {
+		t.Errorf("Mahalanobis(...) with missing coordinate got %v, want %v", d, math.Sqrt2)
+	}
+
+	// correlated covariance: distance along the correlation is shorter
+	metric, err = NewMahalanobis(Matrix{{1, 0.9}, {0.9, 1}})
+	if err != nil {
+		t.Fatalf("NewMahalanobis(...) returned error: %v", err)
+	}
+	if along, across := metric(Vector{0, 0}, Vector{1, 1}), metric(Vector{0, 0}, Vector{1, -1}); along >= across {
+		t.Errorf("Mahalanobis(...) got %v along the correlation, %v across", along, across)
+	}
+
+	// singularity does not depend on the scale of the covariance matrix
+	for _, scale := range []float64{1e-15, 1, 1e15} {
+		S := Matrix{{1 * scale, 2 * scale}, {2 * scale, 4.1 * scale}}
+		if _, err := NewMahalanobis(S); err != nil {
+			t.Errorf("NewMahalanobis(%v) returned error: %v", S, err)
+		}
+		S[1][1] = 4 * scale
+		if _, err := NewMahalanobis(S); err == nil {
+			t.Errorf("NewMahalanobis(%v) of singular matrix returned no error", S)
+		}
+	}
+	if metric, err = NewMahalanobis(Matrix{{4e-14, 0}, {0, 1e-14}}); err != nil {
+		t.Fatalf("NewMahalanobis(...) returned error: %v", err)
+	}
+	if d := metric(Vector{0, 0}, Vector{2e-7, 1e-7}); math.Abs(d - math.Sqrt2) > 1e-9 {
+		t.Errorf("Mahalanobis(...) with small covariance got %v, want %v", d, math.Sqrt2)
+	}
+}
@@ -0,0 +1,178 @@
+package cluster
+
+import (
+	"context"
+	"github.com/NullHypothesis/mlgo"
+)
+
+// MiniBatchKMeans is k-means trained on small random batches of data points
+// (Sculley, 2010). Each center is moved towards the batch members assigned to
+// it with a per-center learning rate that decreases as 1/(number of members
+// seen so far). The model can also be trained incrementally by PartialFit.
+type MiniBatchKMeans struct {
+	KMeans
+	// Number of data points in each batch
+	BatchSize int
+	// number of data points assigned to each center so far
+	counts []int
+}
+
+func NewMiniBatchKMeans(X Matrix, metric MetricOp, batchSize int) *MiniBatchKMeans {
+	return &MiniBatchKMeans{
+		KMeans: *NewKMeans(X, metric),
+		BatchSize: batchSize,
+	}
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+func (c *MiniBatchKMeans) Clone() Cloner {
+	return &MiniBatchKMeans{
+		KMeans: *c.KMeans.clone(),
+		BatchSize: c.BatchSize,
+		counts: append([]int(nil), c.counts...),
+	}
+}
+
+// Cluster runs mini-batch k-means for MaxIter batches (100 by default),
+// then assigns all data points to the nearest centers.
+// Returns the classification information
+func (c *MiniBatchKMeans) Cluster(k int) (classes *Classes) {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid,
+// or along with the classification information if a cluster is empty.
+func (c *MiniBatchKMeans) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before all batches are processed.
+// Tolerance and OnIteration are not used, since the cost is only calculated at the end.
+func (c *MiniBatchKMeans) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	if err = c.check(k); err != nil {
+		return
+	}
+	c.K = k
+	c.initialize()
+	c.counts = make([]int, k)
+
+	iterations := c.MaxIter
+	if iterations == 0 {
+		iterations = 100
+	}
+	size := c.BatchSize
+	if size <= 0 || size > c.Len() {
+		size = c.Len()
+	}
+
+	batch := make(Matrix, size)
+	for t := 0; t < iterations; t++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		// sample batch with replacement
+		for b := range batch {
+			batch[b] = c.X[ c.Index[c.rng.Intn(c.Len())] ]
+		}
+		c.update(batch)
+	}
+
+	c.assign()
+
+	// copy classifcation information
+	classes = c.classes()
+	err = checkEmpty(classes)
+
+	return
+}
+
+// PartialFit updates the centers with a batch of data points.
+// On the first call, K centers are seeded from the batch.
+// Returns an error if K < 1, if the first batch has fewer than K data points,
+// or if the data points are invalid or differ in dimension from the centers.
+func (c *MiniBatchKMeans) PartialFit(batch Matrix) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := checkData(batch, nil); err != nil {
+		return err
+	}
+	n := len(batch[0])
+	if len(c.Centers) == 0 {
+		if err := checkK(c.K, len(batch), len(batch)); err != nil {
+			return err
+		}
+		if c.Seeding == UserSeeding {
+			for i, x := range c.InitialCenters {
+				if len(x) != n {
+					return &DimensionError{i, len(x), n}
+				}
+			}
+		}
+		// seed centers from the first batch, using the configured strategy
+		seeder := &KMeans{
+			X: batch,
+			Metric: c.Metric,
+			K: c.K,
+			Index: mlgo.Range(0, len(batch)),
+			Seeding: c.Seeding,
+			SquaredMetric: c.SquaredMetric,
+			InitialCenters: c.InitialCenters,
+			Source: c.Source,
+		}
+		seeder.initialize()
+		c.Centers, c.Errors = seeder.Centers, seeder.Errors
+		c.counts = make([]int, c.K)
+	} else if want := len(c.Centers[0]); n != want {
+		return &DimensionError{0, n, want}
+	}
+	c.update(batch)
+	return nil
+}
+
+// update moves the centers towards the members of the batch
+func (c *MiniBatchKMeans) update(batch Matrix) {
+	// assign batch members to the centers before they are moved
+	assigned := make([]int, len(batch))
+	for b, x := range batch {
+		assigned[b], _ = c.nearest(x)
+	}
+
+	for b, x := range batch {
+		ii := assigned[b]
+		c.counts[ii]++
+		// per-center learning rate
+		eta := 1 / float64(c.counts[ii])
+		center := c.Centers[ii]
+		for j := range center {
+			center[j] = (1 - eta) * center[j] + eta * x[j]
+		}
+	}
+}
+
+// assign all data points to the nearest centers and calculate the cost
+func (c *MiniBatchKMeans) assign() {
+	for ii := range c.Errors {
+		c.Errors[ii] = 0
+	}
+	J := 0.0
+	for i := range c.Clusters {
+		var d float64
+		c.Clusters[i], d = c.nearest(c.X[c.Index[i]])
+		c.Errors[c.Clusters[i]] += d
+		J += d
+	}
+	c.Cost = J / float64(c.Len())
+}
+
+// nearest returns the index of the nearest center to x and the distance to it
+func (c *MiniBatchKMeans) nearest(x Vector) (center int, min float64) {
+	min = maxValue
+	for ii := range c.Centers {
+		if d := c.Metric(x, c.Centers[ii]); d < min {
+			center, min = ii, d
+		}
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMiniBatchKMeans(t *testing.T) {
+	for i, test := range kmeansTests {
+		c := NewMiniBatchKMeans(test.x, test.metric, 4)
+		c.Seeding = KMeansPlusPlus
+		classes := c.Cluster(test.k)
+		if !classes.Index.Equal(test.partitions) {
+			t.Errorf("#%d MiniBatchKMeans.Cluster(%d) got %v, want %v", i, test.k, classes.Index, test.partitions)
+		}
+		if p := c.Predict(test.x); !p.Equal(test.partitions) {
+			t.Errorf("#%d MiniBatchKMeans.Predict(...) got %v, want %v", i, p, test.partitions)
+		}
+	}
+}
+
+func TestMiniBatchKMeansPartialFit(t *testing.T) {
+	for i, test := range kmeansTests {
+		c := NewMiniBatchKMeans(nil, test.metric, 0)
+		c.K, c.Seeding = test.k, UserSeeding
+		c.InitialCenters = Matrix{test.x[0], test.x[len(test.x)-1]}
+		// stream the data points in batches of two, repeatedly
+		for r := 0; r < 10; r++ {
+			for b := 0; b < len(test.x); b += 2 {
+				if err := c.PartialFit(test.x[b:b+2]); err != nil {
+					t.Fatalf("#%d MiniBatchKMeans.PartialFit(...) returned error: %v", i, err)
+				}
+			}
+		}
+		if p := c.Predict(test.x); !p.Equal(test.partitions) {
+			t.Errorf("#%d MiniBatchKMeans.Predict(...) after PartialFit got %v, want %v", i, p, test.partitions)
+		}
+		if !CoordinatesSetEqual(c.Centers, test.centers) {
+			t.Errorf("#%d MiniBatchKMeans.PartialFit(...) got centers %v, want %v", i, c.Centers, test.centers)
+		}
+	}
+}
+
+func TestMiniBatchKMeansPartialFitErrors(t *testing.T) {
+	batch := Matrix{{1, 2}, {2, 3}, {8, 9}}
+	var tests = []struct {
+		k int
+		batches []Matrix
+		err error
+	}{
+		{0, []Matrix{batch}, &KError{0, 3}},
+		{4, []Matrix{batch}, &KError{4, 3}},
+		{2, []Matrix{batch, {{1, 2, 3}}}, &DimensionError{0, 3, 2}},
+		{2, []Matrix{{{1, 2}, {2}}}, &DimensionError{1, 1, 2}},
+		{2, []Matrix{batch, batch}, nil},
+	}
+	for i, test := range tests {
+		c := NewMiniBatchKMeans(nil, Euclidean, 0)
+		c.K = test.k
+		var err error
+		for _, b := range test.batches {
+			if err = c.PartialFit(b); err != nil {
+				break
+			}
+		}
+		if !reflect.DeepEqual(err, test.err) {
+			t.Errorf("#%d MiniBatchKMeans.PartialFit(...) with K = %d got error %v, want %v", i, test.k, err, test.err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"math"
 	"math/rand"
 	"github.com/NullHypothesis/mlgo"
@@ -21,6 +22,17 @@ type MixModel struct {
 	NLogLikelihood float64
 	// Maximum number of iterations
 	MaxIter int
+	// Relative decrease of the negative log likelihood below which iterations
+	// are stopped; disabled if 0
+	Tolerance float64
+	// Called after each iteration with the negative log likelihood as cost, if not nil
+	OnIteration IterationFunc
+	// Source of random numbers; the global source is used if nil
+	Source rand.Source
+	// random numbers drawn from Source
+	rng random
+	// number of data points whose most probable cluster changed in the last expectation step
+	reassigned int
 }
 
 const logProbEpsilon = 0.01
@@ -28,40 +40,77 @@ const logProbEpsilon = 0.01
 // Cluster runs the algorithm once with random initialization
 // Returns the classification information
 func (c *MixModel) Cluster(k int) (classes *Classes) {
-	if c.X == nil {
+	classes, _ = c.ClusterContext(context.Background(), k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points or k are invalid,
+// or along with the classification information if a component has no members.
+func (c *MixModel) Fit(k int) (*Classes, error) {
+	return c.ClusterContext(context.Background(), k)
+}
+
+// ClusterContext is Fit, but returns ctx.Err() if ctx is done before convergence.
+func (c *MixModel) ClusterContext(ctx context.Context, k int) (classes *Classes, err error) {
+	if err = checkData(c.X, nil); err != nil {
+		return
+	}
+	if err = checkK(k, c.Len(), c.Len()); err != nil {
 		return
 	}
 	c.K = k
 	c.initialize()
-	i := 0
-	for !c.expectation() && (c.MaxIter == 0 || i < c.MaxIter) {
-		c.maximization()
-		i++
+	it := iteration{
+		maxIter: c.MaxIter,
+		tolerance: c.Tolerance,
+		callback: c.OnIteration,
+		progress: func() (float64, int) { return c.NLogLikelihood, c.reassigned },
+	}
+	if _, err = it.iterate(ctx, c.expectation, c.maximization); err != nil {
+		return nil, err
 	}
 
 	// copy classification information
 	classes = &Classes{
-		make([]int, len(c.X)), k, c.NLogLikelihood}
+		make([]int, len(c.X)), k, c.NLogLikelihood, nil}
 	for i, pp := range c.posteriors {
-		class, maxPosterior := 0, 0.0
-		for k, p := range pp {
-			if p > maxPosterior {
-				maxPosterior = p
-				class = k
-			}
-		}
-		classes.Index[i] = class
+		classes.Index[i] = mostProbable(pp)
 	}
+	err = checkEmpty(classes)
 
 	return
 }
 
+func (c *MixModel) Len() int {
+	return len(c.X)
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+// The fitted model is copied and data points are shared; the copy draws random
+// numbers from its own source, which is seeded by the source of c.
+func (c *MixModel) Clone() Cloner {
+	d := *c
+	d.posteriors = Matrix(mlgo.Matrix(c.posteriors).Copied())
+	d.Means = Matrix(mlgo.Matrix(c.Means).Copied())
+	d.Variances = Matrix(mlgo.Matrix(c.Variances).Copied())
+	d.Mixings = append(Vector(nil), c.Mixings...)
+	d.Source = deriveSource(c.Source)
+	d.rng = newRandom(d.Source)
+	return &d
+}
+
+// SetSource sets the source of random numbers used for initialization.
+func (c *MixModel) SetSource(src rand.Source) {
+	c.Source = src
+}
+
 // initialize Gaussians randomly
 func (c *MixModel) initialize() {
 	means, variances := mlgo.Matrix(c.X).Summarize()
 	m, n := len(c.X), len(means)
 
 	c.Means, c.Variances, c.Mixings = make(Matrix, c.K), make(Matrix, c.K), make(Vector, c.K)
+	c.rng = newRandom(c.Source)
 
 	c.posteriors = make(Matrix, m)
 
@@ -81,7 +130,7 @@ func (c *MixModel) initialize() {
 		// use mean of each feature plus some noise
 		for j := 0; j < len(means); j++ {
 			sd := math.Sqrt(variances[j])
-			c.Means[k][j] = means[j] + (rand.Float64()*sd - sd/2)
+			c.Means[k][j] = means[j] + (c.rng.Float64()*sd - sd/2)
 		}
 
 		// uniform mixing proportions
@@ -91,6 +140,18 @@ func (c *MixModel) initialize() {
 	//fmt.Println("initial: ", c.Means, c.Variances, c.Mixings)
 }
 
+// mostProbable returns the cluster with the maximum posterior probability
+func mostProbable(posteriors Vector) (class int) {
+	maxPosterior := 0.0
+	for k, p := range posteriors {
+		if p > maxPosterior {
+			maxPosterior = p
+			class = k
+		}
+	}
+	return
+}
+
 type pdf func(float64) float64
 
 func normPdf(mu, sigma2 float64) func(float64) float64 {
@@ -118,7 +179,9 @@ func (c *MixModel) expectation() (converged bool) {
 	// Also calculate the negative log likelihood of the model
 	//   (i.e. the probability of entire data given the mixture model)
 	model := 0.0
+	c.reassigned = 0
 	for i, _ := range c.X {
+		class := mostProbable(c.posteriors[i])
 		px := 0.0
 		for k := 0; k < c.K; k++ {
 			likelihood := 1.0
@@ -134,6 +197,9 @@ func (c *MixModel) expectation() (converged bool) {
 			c.posteriors[i][k] /= px
 		}
 		model -= math.Log(px)
+		if mostProbable(c.posteriors[i]) != class {
+			c.reassigned++
+		}
 	}
 
 	// Check that model negative log likelihood is decreasing
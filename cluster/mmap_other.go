@@ -0,0 +1,17 @@
+//go:build !unix
+
+package cluster
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is not supported: memory mapping requires a unix system
+func mapFile(f *os.File, n int, writable bool) ([]byte, error) {
+	return nil, errors.New("cluster: memory-mapped distances are not supported on this platform")
+}
+
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,268 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+)
+
+// MedoidAlgorithm specifies how KMedoids searches for the medoids.
+type MedoidAlgorithm int
+
+const (
+	// the most central data points are chosen as the initial medoids, then each
+	// medoid is moved to the member of its cluster with the minimum total distance
+	VoronoiIteration MedoidAlgorithm = iota
+	// Partitioning Around Medoids (Kaufman and Rousseeuw, 1990), as in R's
+	// cluster::pam: the medoids are chosen greedily by BUILD, then SWAP
+	// exchanges the medoid and non-medoid that decrease the total distance
+	// the most, until no exchange decreases it
+	PAM
+	// PAM with the SWAP search of Schubert and Rousseeuw (2019), which
+	// evaluates the exchanges with all medoids at once; O(k) times faster
+	FastPAM1
+	// FastPAM1, which additionally performs the best exchange for each
+	// medoid in the same iteration, as long as it still decreases the total distance
+	FastPAM2
+)
+
+// swapSlack guards the SWAP phase against rounding errors: an exchange is only
+// performed if it decreases the total distance by a relative margin.
+const swapSlack = 1e-10
+
+// clusterPAM runs the BUILD and SWAP phases
+func (c *KMedoids) clusterPAM(ctx context.Context, k int) (classes *Classes, err error) {
+	c.K = k
+	c.build()
+	if _, err = c.iteration().iterate(ctx, c.findSwaps, c.swap); err != nil {
+		return nil, err
+	}
+
+	// copy classifcation information
+	classes = c.classes()
+
+	return
+}
+
+// build chooses each medoid in turn s.t. the total distance is minimized
+func (c *KMedoids) build() {
+	m := c.Len()
+	c.medoids = make([]int, 0, c.K)
+	c.Clusters = make([]int, m)
+	c.dNearest, c.dSecond = make(Vector, m), make(Vector, m)
+	for i := range c.dNearest {
+		c.dNearest[i], c.dSecond[i] = maxValue, maxValue
+	}
+
+	for len(c.medoids) < c.K {
+		best, min := -1, maxValue
+		for j := 0; j < m; j++ {
+			if c.isMedoid(j) {
+				continue
+			}
+			total := 0.0
+			for i := 0; i < m; i++ {
+				if d := c.distance(i, j); d < c.dNearest[i] {
+					total += d
+				} else {
+					total += c.dNearest[i]
+				}
+			}
+			if best < 0 || total < min {
+				best, min = j, total
+			}
+		}
+		c.medoids = append(c.medoids, best)
+		c.assign()
+	}
+}
+
+// findSwaps searches for exchanges of a medoid and a non-medoid that decrease the total distance
+// Returns whether no such exchange exists
+func (c *KMedoids) findSwaps() (converged bool) {
+	c.pending = c.pending[:0]
+	if c.Algorithm == PAM || c.K == 1 {
+		// N.B. the removal loss of FastPAM is undefined without a second nearest medoid
+		c.searchPAM()
+	} else {
+		c.searchFastPAM()
+	}
+	return len(c.pending) == 0
+}
+
+// searchPAM evaluates each exchange separately
+func (c *KMedoids) searchPAM() {
+	best, min := [2]int{}, 0.0
+	for ii := range c.medoids {
+		for h := 0; h < c.Len(); h++ {
+			if c.isMedoid(h) {
+				continue
+			}
+			if delta := c.swapDelta(ii, h); delta < min {
+				best, min = [2]int{ii, h}, delta
+			}
+		}
+	}
+	if c.improves(min) {
+		c.pending = append(c.pending, best)
+	}
+}
+
+// searchFastPAM evaluates the exchanges of all medoids with a non-medoid at once
+func (c *KMedoids) searchFastPAM() {
+	m, k := c.Len(), len(c.medoids)
+
+	// loss of removing each medoid: its members move to their second nearest medoids
+	loss := make(Vector, k)
+	for j := 0; j < m; j++ {
+		loss[c.Clusters[j]] += c.dSecond[j] - c.dNearest[j]
+	}
+
+	// best exchange for each medoid
+	best, min := make([]int, k), make(Vector, k)
+	delta := make(Vector, k)
+	for h := 0; h < m; h++ {
+		if c.isMedoid(h) {
+			continue
+		}
+		copy(delta, loss)
+		// gain of adding h, shared by all exchanges
+		shared := 0.0
+		for j := 0; j < m; j++ {
+			d, n := c.distance(j, h), c.Clusters[j]
+			if d < c.dNearest[j] {
+				shared += d - c.dNearest[j]
+				delta[n] += c.dNearest[j] - c.dSecond[j]
+			} else if d < c.dSecond[j] {
+				delta[n] += d - c.dSecond[j]
+			}
+		}
+		for ii := range delta {
+			if delta[ii] + shared < min[ii] {
+				best[ii], min[ii] = h, delta[ii] + shared
+			}
+		}
+	}
+
+	p := make(pairs, 0, k)
+	for ii := range min {
+		if c.improves(min[ii]) {
+			p = append(p, pair{key: min[ii], value: ii})
+		}
+	}
+	sort.Stable(p)
+	if c.Algorithm == FastPAM1 && len(p) > 1 {
+		p = p[:1]
+	}
+	for _, q := range p {
+		c.pending = append(c.pending, [2]int{q.value, best[q.value]})
+	}
+}
+
+// swap performs the pending exchanges and reassigns the data points
+func (c *KMedoids) swap() {
+	old := append([]int(nil), c.Clusters...)
+	for t, s := range c.pending {
+		if t > 0 && (c.isMedoid(s[1]) || !c.improves(c.swapDelta(s[0], s[1]))) {
+			// a previous exchange in this iteration made this one obsolete
+			continue
+		}
+		c.medoids[s[0]] = s[1]
+		c.assign()
+	}
+	c.reassigned = 0
+	for i := range old {
+		if old[i] != c.Clusters[i] {
+			c.reassigned++
+		}
+	}
+}
+
+// swapDelta returns the change of the total distance if medoid ii is exchanged with data point h
+func (c *KMedoids) swapDelta(ii, h int) (delta float64) {
+	for j := 0; j < c.Len(); j++ {
+		d := c.distance(j, h)
+		if c.Clusters[j] == ii {
+			// member of the removed medoid: nearest of h and the second nearest medoid
+			if d < c.dSecond[j] {
+				delta += d - c.dNearest[j]
+			} else {
+				delta += c.dSecond[j] - c.dNearest[j]
+			}
+		} else if d < c.dNearest[j] {
+			delta += d - c.dNearest[j]
+		}
+	}
+	return
+}
+
+// improves returns whether delta decreases the total distance, with slack
+func (c *KMedoids) improves(delta float64) bool {
+	total := 0.0
+	for _, d := range c.dNearest {
+		total += d
+	}
+	return delta < -swapSlack * total
+}
+
+// setMedoids sets the medoids and assigns the data points to them
+func (c *KMedoids) setMedoids(medoids []int) {
+	m := c.Len()
+	c.medoids = medoids
+	c.Clusters = make([]int, m)
+	c.dNearest, c.dSecond = make(Vector, m), make(Vector, m)
+	c.assign()
+}
+
+// assign data points to the nearest and second nearest medoids,
+// and calculate the centers, errors and cost
+func (c *KMedoids) assign() {
+	k := len(c.medoids)
+	// process chunks of data points concurrently
+	parallel(c.Len(), c.Workers, func(start, end int) {
+		for j := start; j < end; j++ {
+			n, dn, ds := 0, maxValue, maxValue
+			for ii := 0; ii < k; ii++ {
+				d := c.distance(j, c.medoids[ii])
+				if d < dn {
+					n, dn, ds = ii, d, dn
+				} else if d < ds {
+					ds = d
+				}
+			}
+			c.Clusters[j], c.dNearest[j], c.dSecond[j] = n, dn, ds
+		}
+	})
+
+	c.Errors = make(Vector, c.K)
+	for j, n := range c.Clusters {
+		c.Errors[n] += c.dNearest[j]
+	}
+	c.Cost = c.totalError() / float64( c.Len() )
+
+	c.Centers, c.Medoids = make(Matrix, k), make([]int, k)
+	for ii, i := range c.medoids {
+		if c.X != nil {
+			c.setCenter(ii, c.X[ c.Index[i] ])
+		}
+		c.Medoids[ii] = c.Index[i]
+	}
+}
+
+// distance returns the distance between data points i and j of the subset,
+// which is computed on demand if there are no precomputed distances
+func (c *KMedoids) distance(i, j int) float64 {
+	if c.D != nil {
+		return c.D.Get(i, j)
+	}
+	return c.Metric(c.X[c.Index[i]], c.X[c.Index[j]])
+}
+
+// isMedoid returns whether data point i is a medoid
+func (c *KMedoids) isMedoid(i int) bool {
+	for _, j := range c.medoids {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
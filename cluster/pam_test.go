@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var pamTests = []struct {
+	x Matrix
+	metric MetricOp
+	k int
+	index Partitions
+	centers Matrix
+}{
+	{
+		Matrix{
+			{-100, -200},
+			{-10, -20},
+			{-10, -18},
+			{ -8, -18},
+			{ -8, -20},
+			{ 10,  20},
+			{ 10,  18},
+			{  8,  18},
+			{  8,  20},
+		},
+		Euclidean,
+		2,
+		// the outlier forms a cluster of its own, since this minimizes the
+		// total distance (169.8, compared to 214.9 for the two groups)
+		Partitions{0, 1, 1, 1, 1, 1, 1, 1, 1},
+		Matrix{
+			{-100, -200},
+			{-8, -18},
+		},
+	},
+	{
+		Matrix{
+			{ 0,  0}, { 1,  0}, {-1,  0}, { 0,  1}, { 0, -1},
+			{10, 10}, {11, 10}, { 9, 10}, {10, 11}, {10,  9},
+			{20, 0}, {21, 1},
+		},
+		Euclidean,
+		3,
+		Partitions{0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 2, 2},
+		Matrix{
+			{0, 0},
+			{10, 10},
+			{20, 0},
+		},
+	},
+	{
+		agriculture,
+		Euclidean,
+		2,
+		Partitions{0, 0, 0, 1, 1, 0, 1, 0, 0, 0, 1, 0},
+		Matrix{
+			{18.7, 3.5},
+			{7.8, 17.4},
+		},
+	},
+}
+
+// agriculture data set of R's cluster package: GNP per capita and
+// percentage of the population working in agriculture of 12 EU countries
+var agriculture = Matrix{
+	{16.8, 2.7}, {21.3, 5.7}, {18.7, 3.5}, {5.9, 22.2}, {11.4, 10.9}, {17.8, 6.0},
+	{10.9, 14.0}, {16.6, 8.5}, {21.0, 3.5}, {16.4, 4.3}, {7.8, 17.4}, {14.0, 2.3},
+}
+
+func TestPAM(t *testing.T) {
+	for i, test := range pamTests {
+		for _, algorithm := range []MedoidAlgorithm{PAM, FastPAM1, FastPAM2} {
+			c := NewKMedoids(test.x, test.metric, nil)
+			c.Algorithm = algorithm
+			classes := c.Cluster(test.k)
+			if !classes.Index.Equal(test.index) {
+				t.Errorf("#%d KMedoids.Cluster(...) with algorithm %d got %v, want %v", i, algorithm, classes.Index, test.index)
+			}
+			if !CoordinatesSetEqual(c.Centers, test.centers) {
+				t.Errorf("#%d KMedoids.Cluster(...) with algorithm %d got %v, want %v", i, algorithm, c.Centers, test.centers)
+			}
+		}
+	}
+}
+
+// TestPAMObjective checks the medoids D and P (rows 3 and 11) and the objective
+// function, i.e. the mean distance to the nearest medoid after BUILD and SWAP,
+// of cluster::pam(agriculture, 2) in R.
+func TestPAMObjective(t *testing.T) {
+	const build, swap = 3.429317, 3.360610
+	c := NewKMedoids(agriculture, Euclidean, nil)
+	c.Algorithm = PAM
+	c.K = 2
+	c.build()
+	if math.Abs(c.Cost - build) > 1e-6 {
+		t.Errorf("KMedoids.build() got objective %v, want %v", c.Cost, build)
+	}
+	classes := c.Cluster(2)
+	if math.Abs(classes.Cost - swap) > 1e-6 {
+		t.Errorf("KMedoids.Cluster(2) got objective %v, want %v", classes.Cost, swap)
+	}
+	medoids := append([]int(nil), c.Medoids...)
+	sort.Ints(medoids)
+	if !reflect.DeepEqual(medoids, []int{2, 10}) {
+		t.Errorf("KMedoids.Cluster(2) got medoids %v, want [2 10]", c.Medoids)
+	}
+}
+
+// totalDistance returns the total distance of the data points to the nearest medoid
+func totalDistance(d *Distances, medoids []int) (total float64) {
+	for j := 0; j < d.Len(); j++ {
+		min := maxValue
+		for _, i := range medoids {
+			if x := d.Get(j, i); x < min {
+				min = x
+			}
+		}
+		total += min
+	}
+	return
+}
+
+func TestPAMLocalOptimum(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	x := make(Matrix, 60)
+	for i := range x {
+		x[i] = Vector{r.NormFloat64() + float64(i % 4) * 3, r.NormFloat64()}
+	}
+	d := NewDistances(x, Euclidean)
+
+	costs := make(map[MedoidAlgorithm]float64)
+	for _, algorithm := range []MedoidAlgorithm{PAM, FastPAM1, FastPAM2} {
+		c := NewKMedoids(x, Euclidean, d)
+		c.Algorithm = algorithm
+		classes := c.Cluster(4)
+		total := totalDistance(d, c.medoids)
+		if want := total / float64(len(x)); math.Abs(classes.Cost - want) > 1e-9 {
+			t.Errorf("KMedoids.Cluster(4) with algorithm %d got cost %v, want %v", algorithm, classes.Cost, want)
+		}
+		costs[algorithm] = total
+
+		// no exchange of a medoid and a non-medoid decreases the total distance
+		medoids := append([]int(nil), c.medoids...)
+		for ii := range medoids {
+			for h := 0; h < len(x); h++ {
+				if c.isMedoid(h) {
+					continue
+				}
+				medoids[ii] = h
+				if swapped := totalDistance(d, medoids); swapped < total - 1e-9 {
+					t.Errorf("KMedoids.Cluster(4) with algorithm %d got medoids %v with total distance %v, swapping medoid %d for %d gives %v", algorithm, c.medoids, total, ii, h, swapped)
+				}
+				medoids[ii] = c.medoids[ii]
+			}
+		}
+	}
+
+	// FastPAM1 performs the same exchanges as PAM
+	if costs[PAM] != costs[FastPAM1] {
+		t.Errorf("KMedoids.Cluster(4) got total distance %v with FastPAM1, want %v as with PAM", costs[FastPAM1], costs[PAM])
+	}
+}
@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallel calls fn concurrently for contiguous chunks [start, end) of the
+// indices 0, ..., n-1, using at most the specified number of goroutines
+// (GOMAXPROCS if workers <= 0). It returns when all chunks are processed.
+func parallel(n, workers int, fn func(start, end int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		if n > 0 {
+			fn(0, n)
+		}
+		return
+	}
+
+	size := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// parallelQueue calls fn concurrently for each of the indices 0, ..., n-1, using
+// at most the specified number of goroutines (GOMAXPROCS if workers <= 0).
+// Unlike parallel, indices are taken from a shared queue one at a time,
+// which balances the load if the work per index varies.
+// It returns when all indices are processed.
+func parallelQueue(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	var next int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1) - 1); i < n; i = int(atomic.AddInt64(&next, 1) - 1) {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallel(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 100} {
+		for _, workers := range []int{0, 1, 3, 8, 200} {
+			counts := make([]int, n)
+			parallel(n, workers, func(start, end int) {
+				for i := start; i < end; i++ {
+					counts[i]++
+				}
+			})
+			for i, count := range counts {
+				if count != 1 {
+					t.Errorf("parallel(%d, %d, ...) processed index %d %d times", n, workers, i, count)
+				}
+			}
+		}
+	}
+}
+
+func TestParallelQueue(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 100} {
+		for _, workers := range []int{0, 1, 3, 8, 200} {
+			counts := make([]int32, n)
+			parallelQueue(n, workers, func(i int) {
+				atomic.AddInt32(&counts[i], 1)
+			})
+			for i, count := range counts {
+				if count != 1 {
+					t.Errorf("parallelQueue(%d, %d, ...) processed index %d %d times", n, workers, i, count)
+				}
+			}
+		}
+	}
+}
+
+func TestKMeansWorkers(t *testing.T) {
+	for i, test := range kmeansTests {
+		var want *Classes
+		for _, workers := range []int{1, 2, 3, 0} {
+			c := NewKMeans(test.x, test.metric)
+			c.Workers, c.Seeding = workers, UserSeeding
+			c.InitialCenters = Matrix{test.x[0], test.x[1]}
+			classes := c.Cluster(test.k)
+			if want == nil {
+				want = classes
+			} else if !classes.Index.Equal(want.Index) || classes.Cost != want.Cost {
+				t.Errorf("#%d KMeans.Cluster(%d) with %d workers got %v, want %v", i, test.k, workers, classes, want)
+			}
+		}
+	}
+}
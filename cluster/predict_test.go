@@ -0,0 +1,47 @@
+package cluster
+
+import (
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+func TestPredict(t *testing.T) {
+	x := Matrix{
+		{-10, -20}, {-10, -18}, { -8, -18}, { -8, -20},
+		{ 10,  20}, { 10,  18}, {  8,  18}, {  8,  20},
+	}
+	initial := Matrix{{-10, -20}, {10, 20}}
+	unseen := Matrix{{-12, -19}, {7, 21}, {1, 1}}
+
+	kmeans, kmedians := NewKMeans(x, Euclidean), NewKMedians(x, Euclidean)
+	kmeans.Seeding, kmeans.InitialCenters = UserSeeding, initial
+	kmedians.Seeding, kmedians.InitialCenters = UserSeeding, initial
+
+	for _, c := range []interface{ Clusterer; Predictor }{kmeans, kmedians, NewKMedoids(x, Euclidean, nil)} {
+		classes := c.Cluster(2)
+		p := c.Predict(unseen)
+		// unseen points are assigned to the clusters of the nearby training points
+		if p[0] != classes.Index[0] || p[1] != classes.Index[4] {
+			t.Errorf("%T.Predict(%v) got %v, want clusters %d, %d", c, unseen, p, classes.Index[0], classes.Index[4])
+		}
+		if q := c.Predict(x); !q.Equal(classes.Index) {
+			t.Errorf("%T.Predict(X) got %v, want %v", c, q, classes.Index)
+		}
+
+		S := c.Transform(unseen)
+		if len(S) != len(unseen) || len(S[0]) != 2 {
+			t.Fatalf("%T.Transform(...) got %v", c, S)
+		}
+		for i := range S {
+			if S[i][p[i]] > S[i][1-p[i]] {
+				t.Errorf("%T.Transform(...) got %v, inconsistent with Predict %v", c, S, p)
+			}
+		}
+	}
+
+	c := NewKMeans(x, Euclidean)
+	c.Centers = Matrix{{0, 0}, {3, 4}}
+	if S, want := c.Transform(Matrix{{0, 4}}), (Vector{4, 3}); !mlgo.Vector(S[0]).Equal(mlgo.Vector(want)) {
+		t.Errorf("KMeans.Transform(...) got %v, want %v", S, want)
+	}
+}
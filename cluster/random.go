@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"math/rand"
+)
+
+// Randomized is implemented by clusterers that draw random numbers,
+// s.t. their results can be reproduced by supplying a seeded source.
+type Randomized interface {
+	// SetSource sets the source of random numbers; nil selects the global source.
+	SetSource(src rand.Source)
+}
+
+// random numbers used by the clustering algorithms
+type random interface {
+	Intn(n int) int
+	Float64() float64
+	Perm(n int) []int
+}
+
+// globalRandom draws from the global source of package math/rand,
+// which is safe for concurrent use
+type globalRandom struct{}
+
+func (globalRandom) Intn(n int) int { return rand.Intn(n) }
+func (globalRandom) Float64() float64 { return rand.Float64() }
+func (globalRandom) Perm(n int) []int { return rand.Perm(n) }
+
+// newRandom returns random numbers drawn from src, or from the global source if src is nil
+func newRandom(src rand.Source) random {
+	if src == nil {
+		return globalRandom{}
+	}
+	return rand.New(src)
+}
+
+// deriveSource returns a new source seeded by src, s.t. a clone draws random
+// numbers independently of the original; nil (the global source) is kept
+func deriveSource(src rand.Source) rand.Source {
+	if src == nil {
+		return nil
+	}
+	return rand.NewSource(src.Int63())
+}
+
+// NewSources derives the specified number of independent sources from seed,
+// e.g. one for each repeat of a randomized clusterer.
+func NewSources(seed int64, n int) []rand.Source {
+	r := rand.New(rand.NewSource(seed))
+	sources := make([]rand.Source, n)
+	for i := range sources {
+		sources[i] = rand.NewSource(r.Int63())
+	}
+	return sources
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"math/rand"
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+func TestNewSources(t *testing.T) {
+	a, b := NewSources(7, 3), NewSources(7, 3)
+	for i := range a {
+		if x, y := a[i].Int63(), b[i].Int63(); x != y {
+			t.Errorf("#%d NewSources(7, 3) got %d, want %d", i, y, x)
+		}
+	}
+	if a[0].Int63() == a[1].Int63() {
+		t.Errorf("NewSources(7, 3) got identical sources")
+	}
+}
+
+func TestSeededClusterers(t *testing.T) {
+	x := Matrix{
+		{-10, -20}, {-10, -18}, { -8, -18}, { -8, -20}, { -9, -19},
+		{ 10,  20}, { 10,  18}, {  8,  18}, {  8,  20}, {  9,  19},
+		{ 10, -20}, { 10, -18}, {  8, -18}, {  8, -20}, {  9, -19},
+	}
+	// each clusterer is run twice with the same seed
+	clusterers := []func() Clusterer{
+		func() Clusterer { return NewKMeans(x, Euclidean) },
+		func() Clusterer { c := NewKMeans(x, Euclidean); c.Seeding = KMeansPlusPlus; return c },
+		func() Clusterer { return NewKMedians(x, Manhattan) },
+		func() Clusterer { return NewMiniBatchKMeans(x, Euclidean, 4) },
+		func() Clusterer { return &MixModel{X: x} },
+		func() Clusterer { return NewSOM(x, Euclidean, 1, 3) },
+	}
+	for i, newClusterer := range clusterers {
+		a, b := newClusterer(), newClusterer()
+		a.(Randomized).SetSource(rand.NewSource(int64(i)))
+		b.(Randomized).SetSource(rand.NewSource(int64(i)))
+		p, q := a.Cluster(3), b.Cluster(3)
+		if !p.Index.Equal(q.Index) || p.Cost != q.Cost {
+			t.Errorf("#%d %T.Cluster(3) with the same source got %v and %v", i, a, p, q)
+		}
+
+		p = FindClustersSeed(a, 3, 4, 42)
+		q = FindClustersSeed(b, 3, 4, 42)
+		if !p.Index.Equal(q.Index) || p.Cost != q.Cost {
+			t.Errorf("#%d FindClustersSeed(%T, 3, 4, 42) got %v and %v", i, a, p, q)
+		}
+	}
+}
+
+func TestSeededSOM(t *testing.T) {
+	x := Matrix{{0}, {1}, {2}, {3}, {4}, {5}}
+	a, b := NewSOM(x, Euclidean, 2, 3), NewSOM(x, Euclidean, 2, 3)
+	a.Source, b.Source = rand.NewSource(1), rand.NewSource(1)
+	a.Train()
+	b.Train()
+	for ii := range a.Codebook {
+		if !mlgo.Vector(a.Codebook[ii]).Equal(mlgo.Vector(b.Codebook[ii])) {
+			t.Errorf("SOM.Train() with the same source got codebooks %v and %v", a.Codebook, b.Codebook)
+			break
+		}
+	}
+}
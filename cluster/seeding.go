@@ -0,0 +1,169 @@
+package cluster
+
+import (
+	"math"
+)
+
+// Seeding specifies how the initial centers of KMeans and KMedians are chosen.
+type Seeding int
+
+const (
+	// k distinct data points chosen uniformly at random
+	RandomSeeding Seeding = iota
+	// k-means++: data points chosen with probability proportional to the
+	// squared distance to the nearest center chosen so far
+	KMeansPlusPlus
+	// greedy k-means++: the best of several k-means++ candidates is chosen
+	// in each round, s.t. the total squared distance is minimized
+	GreedyKMeansPlusPlus
+	// centers are copied from KMeans.InitialCenters
+	UserSeeding
+)
+
+// seed chooses k initial centers from the data points.
+// Centers are guaranteed to be distinct, if there are at least k distinct data points.
+func (c *KMeans) seed() {
+	switch c.Seeding {
+		case KMeansPlusPlus:
+			c.seedPlusPlus(1)
+		case GreedyKMeansPlusPlus:
+			// number of candidates suggested by Arthur and Vassilvitskii (2007)
+			c.seedPlusPlus(2 + int(math.Log(float64(c.K))))
+		case UserSeeding:
+			c.seedUser()
+		default:
+			c.seedRandom(0)
+	}
+}
+
+// seedRandom chooses centers k0, ..., K-1 from distinct data points uniformly at random.
+func (c *KMeans) seedRandom(k0 int) {
+	m := c.Len()
+	activeSet := NewActiveSet(m)
+	for k := k0; k < c.K; k++ {
+		i := -1
+		for activeSet.Len() > 0 {
+			i = activeSet.Get( c.rng.Intn(activeSet.Len()) )
+			activeSet.Remove(i)
+			if !c.isCenter(c.X[c.Index[i]], k) {
+				break
+			}
+		}
+		if i < 0 {
+			// fewer than k distinct data points: duplicate centers are unavoidable
+			i = c.rng.Intn(m)
+		}
+		c.setCenter(k, c.X[c.Index[i]])
+	}
+}
+
+// seedPlusPlus chooses centers by D^2 weighting, drawing the specified number
+// of candidates in each round and keeping the one that minimizes the potential.
+// If SquaredMetric is set, the distances are already squared: they are used as
+// weights as they are, s.t. data points are not weighted by D^4.
+func (c *KMeans) seedPlusPlus(candidates int) {
+	m := c.Len()
+
+	// weight returns the squared distance D^2 for the metric distance d
+	weight := func(d float64) float64 { return d * d }
+	if c.SquaredMetric {
+		weight = func(d float64) float64 { return d }
+	}
+
+	// first center is chosen uniformly at random
+	c.setCenter(0, c.X[c.Index[c.rng.Intn(m)]])
+
+	// squared distance of each data point to the nearest center
+	dists := make(Vector, m)
+	potential := 0.0
+	for i := range dists {
+		dists[i] = weight(c.Metric(c.X[c.Index[i]], c.Centers[0]))
+		potential += dists[i]
+	}
+
+	for k := 1; k < c.K; k++ {
+		if potential <= 0 {
+			// all data points coincide with centers: no distinct data point remains
+			c.seedRandom(k)
+			return
+		}
+
+		best, bestPotential := -1, math.Inf(1)
+		for t := 0; t < candidates; t++ {
+			// sample data point with probability proportional to D^2
+			r := c.rng.Float64() * potential
+			candidate := 0
+			for ; candidate < m-1; candidate++ {
+				if r -= dists[candidate]; r < 0 && dists[candidate] > 0 {
+					break
+				}
+			}
+			for dists[candidate] == 0 {
+				// guard against rounding errors: never choose an existing center
+				candidate--
+			}
+
+			if candidates == 1 {
+				best = candidate
+				break
+			}
+
+			// potential if the candidate is added as center
+			p := 0.0
+			for i := range dists {
+				d := weight(c.Metric(c.X[c.Index[i]], c.X[c.Index[candidate]]))
+				p += math.Min(dists[i], d)
+			}
+			if p < bestPotential {
+				best, bestPotential = candidate, p
+			}
+		}
+
+		c.setCenter(k, c.X[c.Index[best]])
+
+		// update distances to the nearest center
+		potential = 0
+		for i := range dists {
+			if d := weight(c.Metric(c.X[c.Index[i]], c.Centers[k])); d < dists[i] {
+				dists[i] = d
+			}
+			potential += dists[i]
+		}
+	}
+}
+
+// seedUser copies the user-supplied initial centers;
+// missing centers are chosen at random.
+func (c *KMeans) seedUser() {
+	n := len(c.InitialCenters)
+	if n > c.K {
+		n = c.K
+	}
+	for k := 0; k < n; k++ {
+		c.setCenter(k, c.InitialCenters[k])
+	}
+	c.seedRandom(n)
+}
+
+// isCenter returns whether x has the same coordinates as any of the first k centers.
+func (c *KMeans) isCenter(x Vector, k int) bool {
+	for kk := 0; kk < k; kk++ {
+		equal := true
+		for j := range x {
+			if x[j] != c.Centers[kk][j] {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return true
+		}
+	}
+	return false
+}
+
+// setCenter sets center k to a copy of x.
+func (c *KMeans) setCenter(k int, x Vector) {
+	c.Centers[k] = make(Vector, len(x))
+	copy(c.Centers[k], x)
+}
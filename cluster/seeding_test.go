@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+var seedingTests = []struct {
+	x Matrix
+	k int
+	centers Matrix
+}{
+	{
+		// ties: most data points are duplicates
+		Matrix{
+			{0, 0}, {0, 0}, {0, 0}, {0, 0}, {0, 0}, {0, 0},
+			{5, 5}, {0, 0}, {0, 0}, {10, 10}, {0, 0}, {0, 0},
+		},
+		3,
+		Matrix{
+			{0, 0}, {5, 5}, {10, 10},
+		},
+	},
+}
+
+func TestSeeding(t *testing.T) {
+	seedings := []Seeding{RandomSeeding, KMeansPlusPlus, GreedyKMeansPlusPlus}
+	for i, test := range seedingTests {
+		for _, seeding := range seedings {
+			// repeat, since seeding is random
+			for r := 0; r < 20; r++ {
+				c := NewKMeans(test.x, Euclidean)
+				c.Seeding = seeding
+				c.K = test.k
+				c.initialize()
+				if !CoordinatesSetEqual(c.Centers, test.centers) {
+					t.Fatalf("#%d KMeans.initialize() with seeding %d got %v, want %v", i, seeding, c.Centers, test.centers)
+				}
+			}
+		}
+	}
+}
+
+func TestUserSeeding(t *testing.T) {
+	test := kmeansTests[0]
+	initial := Matrix{{-10, -20}, {10, 20}}
+
+	c := NewKMedians(test.x, test.metric)
+	c.Seeding, c.InitialCenters = UserSeeding, initial
+	c.K = test.k
+	c.initialize()
+	if !CoordinatesSetEqual(c.Centers, initial) {
+		t.Errorf("KMedians.initialize() with UserSeeding got %v, want %v", c.Centers, initial)
+	}
+	// centers must be copied
+	c.Centers[0][0] = 0
+	if initial[0][0] != -10 {
+		t.Errorf("KMedians.initialize() with UserSeeding modified the initial centers")
+	}
+
+	classes := c.Cluster(test.k)
+	if !classes.Index.Equal(test.partitions) {
+		t.Errorf("KMedians.Cluster(%d) with UserSeeding got %v, want %v", test.k, classes.Index, test.partitions)
+	}
+}
+
+// fixedRandom returns fixed values instead of random numbers
+type fixedRandom struct {
+	n int
+	f float64
+}
+
+func (r fixedRandom) Intn(n int) int { return r.n }
+func (r fixedRandom) Float64() float64 { return r.f }
+func (r fixedRandom) Perm(n int) []int { return mlgo.Range(0, n) }
+
+func TestSeedPlusPlusWeights(t *testing.T) {
+	x := Matrix{{0}, {1}, {3}}
+	// the first center is {0}: the D^2 weights of the others are 1 and 9,
+	// s.t. {1} is chosen for r < 0.1 (but only for r < 1/82 with D^4 weights)
+	metrics := []struct {
+		metric MetricOp
+		squared bool
+	}{
+		{Euclidean, false},
+		{EuclideanSq, true},
+	}
+	for _, m := range metrics {
+		c := NewKMeans(x, m.metric)
+		c.SquaredMetric = m.squared
+		c.K, c.Centers = 2, make(Matrix, 2)
+		c.rng = fixedRandom{0, 0.05}
+		c.seedPlusPlus(1)
+		if c.Centers[1][0] != 1 {
+			t.Errorf("KMeans.seedPlusPlus(1) got centers %v, want [[0] [1]]", c.Centers)
+		}
+	}
+}
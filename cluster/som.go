@@ -1,3 +1,313 @@
 package cluster
 
+import (
+	"math"
+	"math/rand"
+	"github.com/NullHypothesis/mlgo"
+)
+
 // Self organizing map
+
+// Topology of the grid of nodes of a self organizing map
+type Topology int
+
+const (
+	RectangularGrid Topology = iota
+	HexagonalGrid
+)
+
+// Neighbourhood function of a self organizing map
+type Neighbourhood int
+
+const (
+	GaussianNeighbourhood Neighbourhood = iota
+	BubbleNeighbourhood
+)
+
+type SOM struct {
+	// Matrix of data points [m x n]
+	X Matrix
+	// Distance metric for finding best-matching units
+	Metric MetricOp
+	// Dimensions of the grid of nodes
+	Rows, Cols int
+	// Grid topology
+	Topology Topology
+	// Neighbourhood function
+	Neighbourhood Neighbourhood
+	// Initial and final learning rates, decaying linearly
+	Alpha [2]float64
+	// Initial and final neighbourhood radii (in grid units), decaying linearly
+	// If the initial radius is 0, it is set to 2/3 of the maximum grid distance
+	Radius [2]float64
+	// Number of passes over the data points
+	MaxIter int
+	// Use batch training instead of online training
+	Batch bool
+	// Matrix of codebook vectors, one for each node [rows*cols x n]
+	Codebook Matrix
+	// best-matching unit of each data point
+	Clusters []int
+	// mean distance of data points to their best-matching units
+	Cost float64
+	// Source of random numbers; the global source is used if nil
+	Source rand.Source
+	// coordinates of the nodes on the grid [rows*cols x 2]
+	grid Matrix
+	// dimensions and topology of the grid that the coordinates were calculated for
+	gridRows, gridCols int
+	gridTopology Topology
+	// neighbourhood radii in effect
+	radius [2]float64
+	// random numbers drawn from Source
+	rng random
+}
+
+func NewSOM(X Matrix, metric MetricOp, rows, cols int) *SOM {
+	return &SOM{
+		X: X,
+		Metric: metric,
+		Rows: rows,
+		Cols: cols,
+		Alpha: [2]float64{0.05, 0.01},
+		MaxIter: 100,
+	}
+}
+
+// Cluster trains the map with k nodes, each of which becomes a cluster.
+// If Rows and Cols are 0, they are set to the most square dimensions with
+// k nodes; otherwise, the grid must have k nodes.
+// Returns the classification information.
+func (c *SOM) Cluster(k int) (classes *Classes) {
+	classes, _ = c.Fit(k)
+	return
+}
+
+// Fit is Cluster, but returns an error if the data points are invalid, k < 1,
+// or the grid does not have k nodes. Nodes without members are not an error.
+func (c *SOM) Fit(k int) (classes *Classes, err error) {
+	if err = checkData(c.X, nil); err != nil {
+		return
+	}
+	if k < 1 {
+		return nil, &KError{k, len(c.X)}
+	}
+	if c.Rows == 0 && c.Cols == 0 {
+		c.Rows = int(math.Sqrt(float64(k)))
+		for k % c.Rows != 0 {
+			c.Rows--
+		}
+		c.Cols = k / c.Rows
+	} else if c.Rows * c.Cols != k {
+		return nil, &KError{k, len(c.X)}
+	}
+
+	c.Train()
+
+	// copy classification information
+	classes = &Classes{
+		make([]int, c.Len()), k, c.Cost, nil }
+	copy(classes.Index, c.Clusters)
+
+	return
+}
+
+func (c *SOM) Len() int {
+	return len(c.X)
+}
+
+// Train initializes the codebook vectors and trains the map.
+func (c *SOM) Train() {
+	c.initialize()
+	if c.Batch {
+		c.trainBatch()
+	} else {
+		c.trainOnline()
+	}
+	c.assign()
+}
+
+// Node returns the index of the node at the specified position on the grid.
+func (c *SOM) Node(row, col int) int {
+	return row * c.Cols + col
+}
+
+// GridDistance returns the distance between nodes i and j on the grid.
+func (c *SOM) GridDistance(i, j int) float64 {
+	return Euclidean(c.grid[i], c.grid[j])
+}
+
+// Clone returns a copy of the clusterer, which can be run concurrently with c.
+// The fitted model is copied and data points are shared; the copy draws random
+// numbers from its own source, which is seeded by the source of c.
+func (c *SOM) Clone() Cloner {
+	d := *c
+	d.Codebook = Matrix(mlgo.Matrix(c.Codebook).Copied())
+	d.Clusters = append([]int(nil), c.Clusters...)
+	d.Source = deriveSource(c.Source)
+	d.rng = newRandom(d.Source)
+	return &d
+}
+
+// SetSource sets the source of random numbers used for initialization and online training.
+func (c *SOM) SetSource(src rand.Source) {
+	c.Source = src
+}
+
+// initialize the grid and the codebook vectors by randomly selecting data points
+func (c *SOM) initialize() {
+	k := c.Rows * c.Cols
+	m := c.Len()
+
+	c.initGrid()
+
+	c.radius = c.Radius
+	if c.radius[0] == 0 {
+		max := 0.0
+		for i := 0; i < k; i++ {
+			for j := i+1; j < k; j++ {
+				if d := c.GridDistance(i, j); d > max {
+					max = d
+				}
+			}
+		}
+		c.radius[0] = max * 2 / 3
+	}
+
+	c.Codebook = make(Matrix, k)
+	c.Clusters = make([]int, m)
+	c.rng = newRandom(c.Source)
+	perm := c.rng.Perm(m)
+	for ii := range c.Codebook {
+		// sample without replacement, if data permit
+		i := c.rng.Intn(m)
+		if k <= m {
+			i = perm[ii]
+		}
+		x := c.X[i]
+		c.Codebook[ii] = make(Vector, len(x))
+		copy(c.Codebook[ii], x)
+	}
+}
+
+// initGrid calculates the coordinates of the nodes on the grid
+func (c *SOM) initGrid() {
+	k := c.Rows * c.Cols
+	c.grid = make(Matrix, k)
+	for row := 0; row < c.Rows; row++ {
+		for col := 0; col < c.Cols; col++ {
+			x, y := float64(col), float64(row)
+			if c.Topology == HexagonalGrid {
+				// odd rows are shifted by half a unit; rows are sqrt(3)/2 units apart
+				x += 0.5 * float64(row % 2)
+				y *= math.Sqrt(3) / 2
+			}
+			c.grid[c.Node(row, col)] = Vector{x, y}
+		}
+	}
+	c.gridRows, c.gridCols, c.gridTopology = c.Rows, c.Cols, c.Topology
+}
+
+// schedule returns the learning rate and neighbourhood radius at time t of T
+func (c *SOM) schedule(t, T float64) (alpha, radius float64) {
+	f := t / T
+	alpha = c.Alpha[0] + (c.Alpha[1] - c.Alpha[0]) * f
+	radius = c.radius[0] + (c.radius[1] - c.radius[0]) * f
+	return
+}
+
+// neighbourhood returns the weight of a node at grid distance d from the
+// best-matching unit
+func (c *SOM) neighbourhood(d, radius float64) float64 {
+	if radius <= 0 {
+		// only the best-matching unit is updated
+		if d == 0 {
+			return 1
+		}
+		return 0
+	}
+	switch c.Neighbourhood {
+		case BubbleNeighbourhood:
+			if d <= radius {
+				return 1
+			}
+			return 0
+	}
+	return math.Exp(-d * d / (2 * radius * radius))
+}
+
+// bmu returns the best-matching unit of data point x and the distance to it
+func (c *SOM) bmu(x Vector) (node int, min float64) {
+	min = maxValue
+	for ii, w := range c.Codebook {
+		if d := c.Metric(x, w); d < min {
+			node, min = ii, d
+		}
+	}
+	return
+}
+
+// online training: update the codebook with one random data point at a time
+func (c *SOM) trainOnline() {
+	m := c.Len()
+	T := float64(c.MaxIter * m)
+	t := 0
+	for iter := 0; iter < c.MaxIter; iter++ {
+		for _, i := range c.rng.Perm(m) {
+			alpha, radius := c.schedule(float64(t), T)
+			x := c.X[i]
+			bmu, _ := c.bmu(x)
+			for ii, w := range c.Codebook {
+				h := c.neighbourhood(c.GridDistance(bmu, ii), radius)
+				if h == 0 { continue }
+				for j := range w {
+					w[j] += alpha * h * (x[j] - w[j])
+				}
+			}
+			t++
+		}
+	}
+}
+
+// batch training: replace each codebook vector by the neighbourhood-weighted
+// mean of all data points
+func (c *SOM) trainBatch() {
+	m, k := c.Len(), len(c.Codebook)
+	bmus := make([]int, m)
+	for iter := 0; iter < c.MaxIter; iter++ {
+		_, radius := c.schedule(float64(iter), float64(c.MaxIter))
+		for i, x := range c.X {
+			bmus[i], _ = c.bmu(x)
+		}
+		for ii := 0; ii < k; ii++ {
+			w := c.Codebook[ii]
+			sum, weights := make(Vector, len(w)), 0.0
+			for i, x := range c.X {
+				h := c.neighbourhood(c.GridDistance(bmus[i], ii), radius)
+				if h == 0 { continue }
+				for j := range x {
+					sum[j] += h * x[j]
+				}
+				weights += h
+			}
+			if weights == 0 {
+				// no data point in the neighbourhood: leave node unchanged
+				continue
+			}
+			for j := range w {
+				w[j] = sum[j] / weights
+			}
+		}
+	}
+}
+
+// assign data points to their best-matching units and calculate the cost
+func (c *SOM) assign() {
+	J := 0.0
+	for i, x := range c.X {
+		var d float64
+		c.Clusters[i], d = c.bmu(x)
+		J += d
+	}
+	c.Cost = J / float64(c.Len())
+}
@@ -0,0 +1,186 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Diagnostics of trained self organizing maps
+// Grids are returned as [rows x cols] matrices.
+
+// Neighbours returns the nodes adjacent to node i on the grid.
+func (c *SOM) Neighbours(i int) (neighbours []int) {
+	c.checkGrid()
+	for j := range c.grid {
+		// adjacent nodes are 1 grid unit apart in both topologies
+		if j != i && c.GridDistance(i, j) < 1 + 1e-9 {
+			neighbours = append(neighbours, j)
+		}
+	}
+	return
+}
+
+// UMatrix returns the unified distance matrix: the average distance between
+// the codebook vector of each node and those of its neighbours.
+func (c *SOM) UMatrix() (U Matrix) {
+	U = c.newGrid()
+	for row := range U {
+		for col := range U[row] {
+			i := c.Node(row, col)
+			neighbours := c.Neighbours(i)
+			for _, j := range neighbours {
+				U[row][col] += c.Metric(c.Codebook[i], c.Codebook[j])
+			}
+			if len(neighbours) > 0 {
+				U[row][col] /= float64(len(neighbours))
+			}
+		}
+	}
+	return
+}
+
+// ComponentPlane returns the values of feature j of the codebook vectors.
+func (c *SOM) ComponentPlane(j int) (P Matrix) {
+	P = c.newGrid()
+	for row := range P {
+		for col := range P[row] {
+			P[row][col] = c.Codebook[c.Node(row, col)][j]
+		}
+	}
+	return
+}
+
+// ComponentPlanes returns the component planes of all features.
+func (c *SOM) ComponentPlanes() (planes []Matrix) {
+	if len(c.Codebook) == 0 {
+		return
+	}
+	planes = make([]Matrix, len(c.Codebook[0]))
+	for j := range planes {
+		planes[j] = c.ComponentPlane(j)
+	}
+	return
+}
+
+// QuantizationError returns the mean distance between the data points
+// and the codebook vectors of their best-matching units.
+func (c *SOM) QuantizationError() (e float64) {
+	for _, x := range c.X {
+		_, d := c.bmu(x)
+		e += d
+	}
+	return e / float64(c.Len())
+}
+
+// TopographicError returns the proportion of data points whose
+// best-matching and second best-matching units are not adjacent on the grid.
+func (c *SOM) TopographicError() (e float64) {
+	c.checkGrid()
+	if len(c.Codebook) < 2 {
+		return
+	}
+	for _, x := range c.X {
+		first, second := -1, -1
+		min1, min2 := maxValue, maxValue
+		for ii, w := range c.Codebook {
+			d := c.Metric(x, w)
+			if d < min1 {
+				second, min2 = first, min1
+				first, min1 = ii, d
+			} else if d < min2 {
+				second, min2 = ii, d
+			}
+		}
+		if c.GridDistance(first, second) > 1 + 1e-9 {
+			e++
+		}
+	}
+	return e / float64(c.Len())
+}
+
+// newGrid allocates a [rows x cols] matrix
+func (c *SOM) newGrid() (G Matrix) {
+	G = make(Matrix, c.Rows)
+	for row := range G {
+		G[row] = make(Vector, c.Cols)
+	}
+	return
+}
+
+// checkGrid calculates the grid coordinates, if the codebook was not trained
+// by Train (e.g. it was loaded or assigned directly) or the dimensions or
+// topology of the grid changed since
+func (c *SOM) checkGrid() {
+	if c.grid == nil || c.gridRows != c.Rows || c.gridCols != c.Cols || c.gridTopology != c.Topology {
+		c.initGrid()
+	}
+}
+
+// WriteGridCSV writes grid G as comma-separated values, one row per line.
+func WriteGridCSV(w io.Writer, G Matrix) error {
+	cw := csv.NewWriter(w)
+	for _, row := range G {
+		record := make([]string, len(row))
+		for j, x := range row {
+			record[j] = strconv.FormatFloat(x, 'g', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSVG writes grid G of the map as an SVG heatmap, with cells of the
+// specified size (in pixels) drawn as squares or hexagons depending on the
+// topology. Values are shaded from white (minimum) to black (maximum).
+func (c *SOM) WriteSVG(w io.Writer, G Matrix, size float64) error {
+	c.checkGrid()
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, row := range G {
+		for _, x := range row {
+			min, max = math.Min(min, x), math.Max(max, x)
+		}
+	}
+
+	// cell centers are offset by one cell radius from the edges
+	r := size / 2
+	width, height := (float64(c.Cols) + 0.5) * size, (float64(c.Rows) + 0.5) * size
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%g\">\n", width, height)
+	for row := range G {
+		for col, x := range G[row] {
+			// shade of grey: 255 for the minimum, 0 for the maximum
+			shade := 255
+			if max > min {
+				shade = int(math.Round(255 * (max - x) / (max - min)))
+			}
+			fill := fmt.Sprintf("#%02x%02x%02x", shade, shade, shade)
+
+			p := c.grid[c.Node(row, col)]
+			cx, cy := r + p[0] * size, r + p[1] * size
+			if c.Topology == HexagonalGrid {
+				// pointy-topped hexagon that touches its neighbours
+				R := r / math.Cos(math.Pi / 6)
+				fmt.Fprint(bw, "<polygon points=\"")
+				for v := 0; v < 6; v++ {
+					a := math.Pi / 3 * float64(v) + math.Pi / 6
+					fmt.Fprintf(bw, "%.2f,%.2f ", cx + R * math.Cos(a), cy + R * math.Sin(a))
+				}
+				fmt.Fprintf(bw, "\" fill=\"%s\"/>\n", fill)
+			} else {
+				fmt.Fprintf(bw, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%g\" height=\"%g\" fill=\"%s\"/>\n",
+					cx - r, cy - r, size, size, fill)
+			}
+		}
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
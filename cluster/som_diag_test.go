@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"github.com/NullHypothesis/mlgo"
+)
+
+func TestSOMDiagnostics(t *testing.T) {
+	c := NewSOM(Matrix{{0}, {0.9}, {3.2}, {1.9}}, Euclidean, 1, 3)
+	// codebook is folded: the node for 1 is at the end of the map
+	c.Codebook = Matrix{{0}, {3}, {1}}
+
+	U := c.UMatrix()
+	if want := (Vector{3, 2.5, 2}); !mlgo.Vector(U[0]).Equal(mlgo.Vector(want)) {
+		t.Errorf("SOM.UMatrix() got %v, want %v", U, want)
+	}
+
+	P := c.ComponentPlane(0)
+	if want := (Vector{0, 3, 1}); !mlgo.Vector(P[0]).Equal(mlgo.Vector(want)) {
+		t.Errorf("SOM.ComponentPlane(0) got %v, want %v", P, want)
+	}
+
+	if e, want := c.QuantizationError(), (0 + 0.1 + 0.2 + 0.9) / 4; !mlgo.EssentiallyEqual(e, want, 1e-9) {
+		t.Errorf("SOM.QuantizationError() got %v, want %v", e, want)
+	}
+
+	// best-matching units of 0 and 0.9 are at opposite ends of the map
+	if e, want := c.TopographicError(), 0.5; e != want {
+		t.Errorf("SOM.TopographicError() got %v, want %v", e, want)
+	}
+
+	var b bytes.Buffer
+	if err := WriteGridCSV(&b, U); err != nil || b.String() != "3,2.5,2\n" {
+		t.Errorf("WriteGridCSV(...) got %q, %v, want %q", b.String(), err, "3,2.5,2\n")
+	}
+
+	b.Reset()
+	if err := c.WriteSVG(&b, U, 10); err != nil || strings.Count(b.String(), "<rect") != 3 {
+		t.Errorf("SOM.WriteSVG(...) got %q, %v", b.String(), err)
+	}
+
+	c.Topology = HexagonalGrid
+	b.Reset()
+	if err := c.WriteSVG(&b, U, 10); err != nil || strings.Count(b.String(), "<polygon") != 3 {
+		t.Errorf("SOM.WriteSVG(...) got %q, %v", b.String(), err)
+	}
+}
+
+func TestSOMGridChanged(t *testing.T) {
+	c := NewSOM(Matrix{{0}, {1}, {2}, {3}}, Euclidean, 2, 2)
+	c.Codebook = Matrix{{0}, {1}, {2}, {3}}
+	c.UMatrix()
+	if d := c.GridDistance(c.Node(0, 1), c.Node(1, 0)); math.Abs(d - math.Sqrt2) > 1e-9 {
+		t.Errorf("SOM.GridDistance(...) of rectangular grid got %v, want %v", d, math.Sqrt2)
+	}
+	// the grid of the same size is recalculated for the new topology
+	c.Topology = HexagonalGrid
+	c.UMatrix()
+	if d := c.GridDistance(c.Node(0, 1), c.Node(1, 0)); math.Abs(d - 1) > 1e-9 {
+		t.Errorf("SOM.GridDistance(...) of hexagonal grid got %v, want 1", d)
+	}
+}
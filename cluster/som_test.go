@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+var somTests = []struct {
+	x Matrix
+	topology Topology
+	neighbourhood Neighbourhood
+	batch bool
+	k int
+	index Partitions
+}{
+	{
+		Matrix{
+			{-10, -20}, {-10, -18}, { -8, -18}, { -8, -20},
+			{ 10,  20}, { 10,  18}, {  8,  18}, {  8,  20},
+		},
+		RectangularGrid, GaussianNeighbourhood, false,
+		2,
+		Partitions{0, 0, 0, 0, 1, 1, 1, 1},
+	},
+	{
+		Matrix{
+			{-10, -20}, {-10, -18}, { -8, -18}, { -8, -20},
+			{ 10,  20}, { 10,  18}, {  8,  18}, {  8,  20},
+		},
+		HexagonalGrid, BubbleNeighbourhood, true,
+		2,
+		Partitions{0, 0, 0, 0, 1, 1, 1, 1},
+	},
+}
+
+func TestSOM(t *testing.T) {
+	for i, test := range somTests {
+		c := NewSOM(test.x, Euclidean, 0, 0)
+		c.Topology, c.Neighbourhood, c.Batch = test.topology, test.neighbourhood, test.batch
+		classes := c.Cluster(test.k)
+		if !classes.Index.Equal(test.index) {
+			t.Errorf("#%d SOM.Cluster(%d) got %v, want %v", i, test.k, classes.Index, test.index)
+		}
+	}
+}
+
+func TestSOMGrid(t *testing.T) {
+	c := NewSOM(Matrix{{0}, {1}, {2}, {3}, {4}, {5}}, Euclidean, 2, 3)
+	c.Topology = HexagonalGrid
+	c.Train()
+	// in a hexagonal grid, each node is equidistant to its six neighbours
+	for _, j := range []int{c.Node(0, 0), c.Node(0, 2), c.Node(1, 0), c.Node(1, 1)} {
+		if d := c.GridDistance(c.Node(0, 1), j); math.Abs(d - 1) > 1e-9 {
+			t.Errorf("SOM.GridDistance(%d, %d) got %v, want 1", c.Node(0, 1), j, d)
+		}
+	}
+}
+
+func TestSOMFitGrid(t *testing.T) {
+	x := Matrix{{0}, {1}, {2}, {3}, {4}, {5}}
+	c := NewSOM(x, Euclidean, 0, 0)
+	if _, err := c.Fit(6); err != nil || c.Rows != 2 || c.Cols != 3 {
+		t.Errorf("SOM.Fit(6) got grid %dx%d, error %v, want 2x3", c.Rows, c.Cols, err)
+	}
+	// the grid of the caller is not reshaped
+	c = NewSOM(x, Euclidean, 1, 3)
+	want := &KError{4, len(x)}
+	if _, err := c.Fit(4); !reflect.DeepEqual(err, want) || c.Rows != 1 || c.Cols != 3 {
+		t.Errorf("SOM.Fit(4) of 1x3 grid got grid %dx%d, error %v, want 1x3, %v", c.Rows, c.Cols, err, want)
+	}
+}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"fmt"
 	"github.com/NullHypothesis/mlgo"
 	"math"
 )
@@ -13,7 +14,7 @@ import (
 // use update formula?
 
 // Segregations return a matrix of distances between data points and clusters
-func Segregations(distances *Distances, classes *Classes) (S Matrix) {
+func Segregations(distances DistanceProvider, classes *Classes) (S Matrix) {
 	// each row of x is considered one data point
 	m := distances.Len()
 
@@ -82,6 +83,9 @@ func SegregationsFromCenters(X, centers Matrix, metric MetricOp) (S Matrix) {
 // TODO faithful calculation of "shadow" as defined by Friedrich Leisch (average two nearest centroids for 'b')
 func Silhouettes(S Matrix, classes *Classes) (s Vector)  {
 	m := len(S)
+	if m == 0 {
+		return Vector{}
+	}
 	k := len(S[0])
 
 	s = make(Vector, m)
@@ -120,6 +124,32 @@ func Silhouettes(S Matrix, classes *Classes) (s Vector)  {
 	return
 }
 
+// SilhouettesFit is Silhouettes, but returns an error if S is empty,
+// does not have a row for each data point and a column for each class,
+// or contains NaN.
+func SilhouettesFit(S Matrix, classes *Classes) (Vector, error) {
+	if len(S) == 0 {
+		return nil, ErrNoData
+	}
+	if len(classes.Index) != len(S) {
+		return nil, fmt.Errorf("cluster: %d rows of segregations for %d data points", len(S), len(classes.Index))
+	}
+	for i, c := range classes.Index {
+		if c < 0 || c >= classes.K {
+			return nil, fmt.Errorf("cluster: data point %d in class %d of %d", i, c, classes.K)
+		}
+	}
+	for i, x := range S {
+		if len(x) != classes.K {
+			return nil, &DimensionError{i, len(x), classes.K}
+		}
+		if err := checkVector(x, i); err != nil {
+			return nil, err
+		}
+	}
+	return Silhouettes(S, classes), nil
+}
+
 
 type Split struct {
 	K int
@@ -168,11 +198,43 @@ type Splitter interface {
 	Subset(index []int) Splitter
 }
 
-//TODO median split silhouette
-
 // K is the maximum number of clusters.
 // L is the maximum number of children clusters for any cluster.
 func SplitByMeanSplitSil(splitter Splitter, K, L int) (s Split) {
+	return splitBySplitSil(splitter, K, L, func(x Vector) float64 {
+		return mlgo.Vector(x).Mean()
+	})
+}
+
+// SplitByMedianSplitSil chooses the number of clusters that minimizes the
+// median split silhouette (MSS), as used by HOPACH.
+// K is the maximum number of clusters.
+// L is the maximum number of children clusters for any cluster.
+func SplitByMedianSplitSil(splitter Splitter, K, L int) (s Split) {
+	return splitBySplitSil(splitter, K, L, func(x Vector) float64 {
+		if len(x) == 0 {
+			return math.NaN()
+		}
+		// median sorts its argument
+		y := make(Vector, len(x))
+		copy(y, x)
+		return median(y)
+	})
+}
+
+// SplitSil returns the split silhouette of the data points of splitter,
+// i.e. the maximum average silhouette over splits into at most L clusters.
+// Returns false if the data points cannot be split further.
+func SplitSil(splitter Splitter, L int) (sil float64, ok bool) {
+	clustSplit := SegregateByMeanSil(splitter, L)
+	if clustSplit.K == 0 {
+		return
+	}
+	return 1 - clustSplit.Cost, true
+}
+
+// splitBySplitSil minimizes the split silhouettes aggregated by aggregate.
+func splitBySplitSil(splitter Splitter, K, L int, aggregate func(Vector) float64) (s Split) {
 	m := splitter.Len()
 
 	// average split silhouette can be only be calculated for 1 <= k <= m/3
@@ -184,7 +246,7 @@ func SplitByMeanSplitSil(splitter Splitter, K, L int) (s Split) {
 		K = m / 3
 	}
 
-	// minimize the mean split silhouette
+	// minimize the aggregated split silhouette
 	avgSplitSil := math.Inf(1)
 	optK := 0
 	var optClasses *Classes
@@ -194,16 +256,15 @@ func SplitByMeanSplitSil(splitter Splitter, K, L int) (s Split) {
 		partitions := classes.Partitions()
 		n := 0
 		for kk := 0; kk < classes.K; kk++ {
-			clustSplit := SegregateByMeanSil(splitter.Subset(partitions[kk]), L)
-			if clustSplit.K > 0 {
+			if sil, ok := SplitSil(splitter.Subset(partitions[kk]), L); ok {
 				// cluster could be split further into children clusters
-				splitSil[n] = 1 - clustSplit.Cost
+				splitSil[n] = sil
 				n++
 			}
 		}
 		// remove empty elements at end to account for clusters that could be not split further
 		splitSil = splitSil[:n]
-		t := mlgo.Vector(splitSil).Mean()
+		t := aggregate(splitSil)
 		//fmt.Println(k, t, splitSil, classes)
 		if t < avgSplitSil {
 			avgSplitSil = t
@@ -217,4 +278,3 @@ func SplitByMeanSplitSil(splitter Splitter, K, L int) (s Split) {
 	s.Cl = optClasses
 	return
 }
-
@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"github.com/NullHypothesis/mlgo"
+	"math/rand"
 	"testing"
 )
 
@@ -99,6 +100,8 @@ func TestSegregate(t *testing.T) {
 	const K = 9
 	for i, test := range segregateTests {
 		c := NewKMeans(test.x, test.metric)
+		// k-means is randomly initialized: fix the source s.t. the test is reproducible
+		c.Source = rand.NewSource(1)
 		split := SegregateByMeanSil(c, K)
 		if split.K != test.k {
 			t.Errorf("#%d SegregateByMeanSil(*KMeans, %d) got %d, want %d", i, K, split.K, test.k)
@@ -0,0 +1,136 @@
+package mlgo
+
+import (
+	"math"
+	"sort"
+)
+
+// Imputation specifies how Imputed replaces missing values (NaN).
+type Imputation int
+
+const (
+	// mean of the feature
+	ImputeMean Imputation = iota
+	// median of the feature
+	ImputeMedian
+	// mean of the feature over the k nearest data points that have a value,
+	// where the distance is calculated over the features that both data
+	// points have; falls back to the mean if no data point has a value
+	ImputeKNN
+)
+
+// Imputed returns a copy of X with missing values (NaN) replaced according
+// to method. k is the number of neighbours for ImputeKNN (5 if k <= 0).
+// Features without any values are imputed by 0.
+func (X Matrix) Imputed(method Imputation, k int) (Y Matrix) {
+	Y = X.Copied()
+	stats := X.Summaries()
+
+	// value of each feature that replaces missing values
+	fill := make(Vector, len(stats))
+	for j := range stats {
+		if stats[j].Missing == 0 {
+			continue
+		}
+		if method == ImputeMedian {
+			fill[j] = X.median(j)
+		} else {
+			fill[j] = stats[j].Mean
+		}
+	}
+
+	if k <= 0 {
+		k = 5
+	}
+
+	for i, x := range X {
+		var d Vector
+		for j, v := range x {
+			if !math.IsNaN(v) {
+				continue
+			}
+			Y[i][j] = fill[j]
+			if method != ImputeKNN {
+				continue
+			}
+			if d == nil {
+				d = X.completeDistances(i)
+			}
+			if v, ok := X.neighbourMean(d, j, k); ok {
+				Y[i][j] = v
+			}
+		}
+	}
+	return
+}
+
+// median returns the median of the values of feature j, skipping missing values
+func (X Matrix) median(j int) float64 {
+	values := make([]float64, 0, len(X))
+	for _, x := range X {
+		if !math.IsNaN(x[j]) {
+			values = append(values, x[j])
+		}
+	}
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	if n % 2 == 0 {
+		return (values[n/2 - 1] + values[n/2]) / 2
+	}
+	return values[n/2]
+}
+
+// completeDistances returns the Euclidean distances of data point i to all data
+// points, over the features that both have, rescaled to all features;
+// the distance is NaN if they have no features in common, or for i itself
+func (X Matrix) completeDistances(i int) (d Vector) {
+	d = make(Vector, len(X))
+	for l, y := range X {
+		if l == i {
+			d[l] = math.NaN()
+			continue
+		}
+		sum, complete := 0.0, 0
+		for j, v := range X[i] {
+			if math.IsNaN(v) || math.IsNaN(y[j]) {
+				continue
+			}
+			t := y[j] - v
+			sum += t * t
+			complete++
+		}
+		if complete == 0 {
+			d[l] = math.NaN()
+		} else {
+			d[l] = math.Sqrt(sum * float64(len(y)) / float64(complete))
+		}
+	}
+	return
+}
+
+// neighbourMean returns the mean of feature j over the k nearest data points
+// by the distances d that have a value for j, and whether there are any
+func (X Matrix) neighbourMean(d Vector, j, k int) (mean float64, ok bool) {
+	var neighbours []int
+	for l, x := range X {
+		if !math.IsNaN(d[l]) && !math.IsNaN(x[j]) {
+			neighbours = append(neighbours, l)
+		}
+	}
+	if len(neighbours) == 0 {
+		return
+	}
+	sort.SliceStable(neighbours, func(a, b int) bool {
+		return d[neighbours[a]] < d[neighbours[b]]
+	})
+	if len(neighbours) > k {
+		neighbours = neighbours[:k]
+	}
+	for _, l := range neighbours {
+		mean += X[l][j]
+	}
+	return mean / float64(len(neighbours)), true
+}
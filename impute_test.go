@@ -0,0 +1,31 @@
+package mlgo
+
+import (
+	"math"
+	"testing"
+)
+
+var imputeTests = []struct {
+	method Imputation
+	k int
+	imputed Matrix
+}{
+	{ImputeMean, 0, Matrix{{1, 2}, {1.1, 2.2}, {5, 4.6}, {5.2, 3}, {3.075, 3.2}}},
+	{ImputeMedian, 0, Matrix{{1, 2}, {1.1, 2.2}, {5, 4.6}, {5.2, 2.7}, {3.05, 3.2}}},
+	{ImputeKNN, 1, Matrix{{1, 2}, {1.1, 2.2}, {5, 4.6}, {5.2, 4.6}, {1.1, 3.2}}},
+	{ImputeKNN, 2, Matrix{{1, 2}, {1.1, 2.2}, {5, 4.6}, {5.2, 3.4}, {1.05, 3.2}}},
+}
+
+func TestImputed(t *testing.T) {
+	nan := math.NaN()
+	X := Matrix{{1, 2}, {1.1, 2.2}, {5, 4.6}, {5.2, nan}, {nan, 3.2}}
+	for i, test := range imputeTests {
+		Y := X.Imputed(test.method, test.k)
+		if !Y.Equal(test.imputed) {
+			t.Errorf("#%d Matrix.Imputed(%d, %d) got %v, want %v", i, test.method, test.k, Y, test.imputed)
+		}
+	}
+	if !math.IsNaN(X[3][1]) {
+		t.Errorf("Matrix.Imputed(...) modified the original")
+	}
+}
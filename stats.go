@@ -6,11 +6,18 @@ import (
 
 type Summary struct {
 	Mean, N, devsq, Min, Max float64
+	// number of missing values (NaN), which are excluded from the statistics
+	Missing float64
 }
 
 // Add accumulates running statistics for calculating variance and
 // standard deviation using the Welford method (1962)
+// Missing values (NaN) are counted, but not accumulated.
 func (s *Summary) Add(x float64) {
+	if math.IsNaN(x) {
+		s.Missing++
+		return
+	}
 	if s.N > 0 {
 		if x < s.Min { s.Min = x }
 		if x > s.Max { s.Max = x }
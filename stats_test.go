@@ -3,6 +3,7 @@ package mlgo
 import (
 	"testing"
 	"fmt"
+	"math"
 )
 
 func TestSummary(t *testing.T) {
@@ -11,3 +12,24 @@ func TestSummary(t *testing.T) {
 	fmt.Println(s.Mean, s.N, s.Var(), s.VarP(), s.Min, s.Max, s.Range())
 }
 
+
+func TestSummaryMissing(t *testing.T) {
+	s := Summary{}
+	s.AddValues([]float64{1, math.NaN(), 3, math.NaN()})
+	if s.N != 2 || s.Missing != 2 || s.Mean != 2 || s.Min != 1 || s.Max != 3 {
+		t.Errorf("Summary.AddValues(...) got N %v, Missing %v, Mean %v, Min %v, Max %v, want 2, 2, 2, 1, 3",
+			s.N, s.Missing, s.Mean, s.Min, s.Max)
+	}
+}
+
+func TestMatrixSummarizeMissing(t *testing.T) {
+	X := Matrix{{1, math.NaN()}, {3, 4}, {math.NaN(), 8}}
+	means, variances := X.Summarize()
+	if !means.Equal(Vector{2, 6}) || !variances.Equal(Vector{1, 4}) {
+		t.Errorf("Matrix.Summarize() got %v, %v, want [2 6], [1 4]", means, variances)
+	}
+	stats := X.Summaries()
+	if stats[0].Missing != 1 || stats[1].Missing != 1 {
+		t.Errorf("Matrix.Summaries() got %v and %v missing values, want 1", stats[0].Missing, stats[1].Missing)
+	}
+}
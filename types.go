@@ -71,15 +71,34 @@ func (x Vector) Equal(y Vector) bool {
 	return true
 }
 
+// Summarize returns the mean and population variance of each feature of X;
+// missing values (NaN) are skipped.
 func (X Matrix) Summarize() (means, variances Vector) {
 	m := len(X)
 	if m < 2 { return }
 
-	n := len(X[0])
-	stats := make([]Summary, n)
+	stats := X.Summaries()
+	n := len(stats)
 
 	means, variances = make(Vector, n), make(Vector, n)
 
+	for j, _ := range stats {
+		means[j] = stats[j].Mean
+		variances[j] = stats[j].VarP()
+	}
+
+	return
+}
+
+// Summaries returns the summary statistics of each feature of X,
+// including the number of missing values (NaN).
+func (X Matrix) Summaries() (stats []Summary) {
+	m := len(X)
+	if m == 0 { return }
+
+	n := len(X[0])
+	stats = make([]Summary, n)
+
 	for i := 0; i < m; i++ {
 		// accumulate statistics for each feature
 		for j, x := range X[i] {
@@ -87,11 +106,6 @@ func (X Matrix) Summarize() (means, variances Vector) {
 		}
 	}
 
-	for j, _ := range stats {
-		means[j] = stats[j].Mean
-		variances[j] = stats[j].VarP()
-	}
-
 	return
 }
 